@@ -23,6 +23,10 @@ import (
 type Client struct {
 	BaseURL string // base url for doing request to (example "http://some-site.com")
 
+	// Transport used for requests (nil means http.DefaultTransport).
+	// Set it to opentelemetry Tracing.Transport for client spans of OpenTelemetry.
+	Transport http.RoundTripper
+
 	ctx       iriscontext.Context // current iris context
 	requestId string              // request-id extracted from context
 	log       *logrus.Entry       // logger extracted from context
@@ -49,6 +53,16 @@ func (c *Client) WithTrace(traceCtx gocontext.Context) *Client {
 	return c
 }
 
+// Takes request-id, logger and trace from plain go context (see requestid.NewContext and logging.StartJob).
+// Use it instead of WithIris in code which has no access to iris context.
+func (c *Client) WithContext(ctx gocontext.Context) *Client {
+	c.traceCtx = ctx
+	c.requestId, _ = requestid.FromContext(ctx)
+	c.log = logging.FromContext(ctx)
+
+	return c
+}
+
 // simple version of GET which only returns response body for 2xx response codes (and follows redirects)
 func (c *Client) GET(url string, params ...interface{}) (string, error) {
 	url, err := c.joinBaseUrl(url)
@@ -96,23 +110,42 @@ func (c *Client) POST(url string, data map[string]interface{}) (string, error) {
 func (c *Client) doRequest(method string, url string, data string, headers map[string]string) (string, error) {
 	var span opentracing.Span
 
+	reqCtx := gocontext.Background()
+
 	if c.traceCtx != nil {
-		span, _ = opentracing.StartSpanFromContext(c.traceCtx, "doRequest")
+		span, reqCtx = opentracing.StartSpanFromContext(c.traceCtx, "doRequest")
 		defer span.Finish()
 	}
 
-	c.log.WithField("http_method", method).Debugf("Request to %s", url)
+	// NOTE: trace context of the request carries request-id too,
+	//       it is taken for each call, because client can be reused with another WithTrace
+	requestId := c.requestId
+	if c.traceCtx != nil && requestId == "" {
+		requestId, _ = requestid.FromContext(c.traceCtx)
+	}
+
+	log := c.log
+	if log == nil {
+		if c.traceCtx != nil {
+			log = logging.FromContext(c.traceCtx)
+		} else {
+			log = logging.FromContext(gocontext.Background())
+		}
+	}
+
+	log.WithField("http_method", method).Debugf("Request to %s", url)
 
 	buffer := bytes.NewBufferString(data)
 
-	req, err := http.NewRequest(method, url, buffer)
+	// NOTE: context of the request carries parent span for Transport
+	req, err := http.NewRequestWithContext(reqCtx, method, url, buffer)
 	if err != nil {
 		return "", err
 	}
 
 	// TODO: headers for json (separate method doJsonRequest?)
-	if c.requestId != "" {
-		req.Header.Set("X-Request-Id", c.requestId) // add Request-Id for each request
+	if requestId != "" {
+		req.Header.Set("X-Request-Id", requestId) // add Request-Id for each request
 	}
 
 	for name, value := range headers {
@@ -120,7 +153,8 @@ func (c *Client) doRequest(method string, url string, data string, headers map[s
 	}
 
 	client := &http.Client{
-		Timeout: 10 * time.Second, // NOTE: very important (default timeout is infinite)
+		Timeout:   10 * time.Second, // NOTE: very important (default timeout is infinite)
+		Transport: c.Transport,
 	}
 
 	// log info about request and inject span into HTTP headers
@@ -163,8 +197,8 @@ func (c *Client) doRequest(method string, url string, data string, headers map[s
 			"headers", resp.Header,
 		)
 	}
-	c.log.Debug("response Status: ", resp.Status)
-	c.log.Debug("response Headers: ", resp.Header)
+	log.Debug("response Status: ", resp.Status)
+	log.Debug("response Headers: ", resp.Header)
 
 	// check for http-code errors
 	if resp.StatusCode != 200 {
@@ -183,7 +217,7 @@ func (c *Client) doRequest(method string, url string, data string, headers map[s
 		)
 	}
 
-	c.log.Debug("response Body: ", string(bytes))
+	log.Debug("response Body: ", string(bytes))
 
 	return string(bytes), nil
 }
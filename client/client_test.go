@@ -0,0 +1,35 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gocontext "context"
+
+	"github.com/ont/iris-related/requestid/v12"
+)
+
+func TestWithTraceTakesRequestIdForEachCall(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Request-Id"))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+
+	ctxA := requestid.NewContext(gocontext.Background(), "req-A")
+	ctxB := requestid.NewContext(gocontext.Background(), "req-B")
+
+	if _, err := c.WithTrace(ctxA).GET("/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.WithTrace(ctxB).GET("/"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received) != 2 || received[0] != "req-A" || received[1] != "req-B" {
+		t.Fatalf("expected request-ids [req-A req-B], got %v", received)
+	}
+}
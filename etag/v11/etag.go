@@ -14,14 +14,21 @@ func Record(ctx iris.Context) {
 }
 
 func Emit(ctx iris.Context) {
-	body := ctx.Recorder().Body()
+	value, err := compute(ctx.Recorder().Body())
+	if err != nil {
+		return
+	}
 
+	ctx.Header("ETag", value)
+}
+
+// Returns ETag value for body in form "<length>-<sha1>".
+func compute(body []byte) (string, error) {
 	hasher := sha1.New()
 	if _, err := hasher.Write(body); err != nil {
-		return
+		return "", err
 	}
 
 	hex := hex.EncodeToString(hasher.Sum(nil))
-	value := fmt.Sprintf("%d-%s", len(body), hex)
-	ctx.Header("ETag", value)
+	return fmt.Sprintf("%d-%s", len(body), hex), nil
 }
@@ -0,0 +1,396 @@
+package etag
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/iris/v12"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Key of cache tags in iris context values (set them by CacheTags).
+// NOTE: iris values are keyed by plain strings, so key is namespaced by package name.
+const cacheTagsKey = "etag.cache-tags"
+
+// Server-side response cache built on top of the response recorder (see Record).
+// Responses are stored per method, path, query and configured Vary headers.
+// Only headers set by next handlers are stored, headers of previous middlewares stay per request.
+// Requests with Authorization or Cookie header share only responses marked with
+// "Cache-Control: public" or "s-maxage" unless the header is one of Vary headers.
+// Usage:
+//
+//	cache := etag.NewCache(etag.WithTTL(time.Minute), etag.WithVary("Accept-Language"))
+//	app.Get("/users/{id}", cache.Handler, usersHandler)
+//	...
+//	cache.InvalidatePrefix("/users/")
+type Cache struct {
+	ttl      time.Duration
+	maxBytes int
+	vary     []string
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List               // most recently used entries are at front
+	entries map[string]*list.Element // key -> element with *cacheEntry
+}
+
+type cacheEntry struct {
+	key     string
+	path    string
+	tags    []string
+	status  int
+	header  map[string][]string
+	body    []byte
+	etag    string
+	size    int
+	expires time.Time
+	public  bool // response may be served for requests with credentials
+}
+
+type CacheOption func(c *Cache)
+
+// Sets lifetime of cached response (default is 1 minute).
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// Sets memory cap for all cached bodies and headers (default is 32Mb).
+// Least recently used responses are evicted first.
+func WithMaxBytes(maxBytes int) CacheOption {
+	return func(c *Cache) {
+		c.maxBytes = maxBytes
+	}
+}
+
+// Sets request headers which values are part of the cache key.
+func WithVary(headers ...string) CacheOption {
+	return func(c *Cache) {
+		c.vary = headers
+	}
+}
+
+func NewCache(opts ...CacheOption) *Cache {
+	c := &Cache{
+		ttl:      time.Minute,
+		maxBytes: 32 << 20,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Marks response of current request with tags for later invalidation via Cache.InvalidateTag.
+// Must be called from handler before response is finished.
+func CacheTags(ctx iris.Context, tags ...string) {
+	if prev, ok := ctx.Values().Get(cacheTagsKey).([]string); ok {
+		tags = append(prev, tags...)
+	}
+	ctx.Values().Set(cacheTagsKey, tags)
+}
+
+// Middleware which serves cached responses without invoking next handlers.
+// Only successful GET responses are stored.
+func (c *Cache) Handler(ctx iris.Context) {
+	if ctx.Method() != iris.MethodGet {
+		ctx.Next()
+		return
+	}
+
+	key := c.key(ctx)
+	credentials := c.hasCredentials(ctx)
+
+	if entry := c.get(key); entry != nil && (entry.public || !credentials) {
+		tagSpan(ctx, true)
+		c.serve(ctx, entry)
+		return
+	}
+
+	tagSpan(ctx, false)
+
+	// NOTE: headers of previous middlewares belong to this request only (for example request id)
+	before := ctx.ResponseWriter().Header().Clone()
+
+	ctx.Record()
+	ctx.Next()
+
+	c.store(ctx, key, credentials, before)
+}
+
+// Removes all cached responses which request path starts with prefix.
+func (c *Cache) InvalidatePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.entries {
+		if strings.HasPrefix(el.Value.(*cacheEntry).path, prefix) {
+			c.remove(el)
+		}
+	}
+}
+
+// Removes all cached responses marked with tag (see CacheTags).
+func (c *Cache) InvalidateTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.entries {
+		for _, t := range el.Value.(*cacheEntry).tags {
+			if t == tag {
+				c.remove(el)
+				break
+			}
+		}
+	}
+}
+
+// Removes all cached responses.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.size = 0
+}
+
+func (c *Cache) key(ctx iris.Context) string {
+	var b strings.Builder
+
+	b.WriteString(ctx.Method())
+	b.WriteString(" ")
+	b.WriteString(ctx.Path())
+
+	// url.Values.Encode sorts params by name, so order of query params doesn't matter
+	if query := ctx.Request().URL.Query(); len(query) > 0 {
+		b.WriteString("?")
+		b.WriteString(query.Encode())
+	}
+
+	for _, name := range c.vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(url.QueryEscape(ctx.GetHeader(name)))
+	}
+
+	return b.String()
+}
+
+// Returns true if request has credentials which aren't part of the cache key.
+// NOTE: response for such request may depend on user, so it can't be shared by default
+func (c *Cache) hasCredentials(ctx iris.Context) bool {
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if ctx.GetHeader(name) != "" && !c.varies(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cache) varies(name string) bool {
+	for _, vary := range c.vary {
+		if strings.EqualFold(vary, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.entries[key]
+	if !found {
+		return nil
+	}
+
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(el)
+		return nil
+	}
+
+	c.lru.MoveToFront(el)
+	return entry
+}
+
+func (c *Cache) serve(ctx iris.Context, entry *cacheEntry) {
+	header := ctx.ResponseWriter().Header()
+	for name, values := range entry.header {
+		// NOTE: headers of current request (set by previous middlewares) win over cached ones
+		if _, found := header[name]; found {
+			continue
+		}
+		header[name] = append([]string(nil), values...)
+	}
+
+	if matchETag(ctx.GetHeader("If-None-Match"), entry.etag) {
+		ctx.WriteNotModified()
+		return
+	}
+
+	ctx.StatusCode(entry.status)
+	ctx.Write(entry.body)
+}
+
+func (c *Cache) store(ctx iris.Context, key string, credentials bool, before http.Header) {
+	if ctx.GetStatusCode() != iris.StatusOK {
+		return
+	}
+
+	header := ctx.ResponseWriter().Header()
+	if !cacheable(header) {
+		return
+	}
+
+	public := cacheablePublicly(header)
+	if credentials && !public {
+		return
+	}
+
+	body := append([]byte(nil), ctx.Recorder().Body()...)
+
+	value := header.Get("ETag")
+	if value == "" {
+		var err error
+		if value, err = compute(body); err != nil {
+			return
+		}
+		header.Set("ETag", value)
+	}
+
+	entry := &cacheEntry{
+		key:     key,
+		path:    ctx.Path(),
+		status:  iris.StatusOK,
+		header:  make(map[string][]string, len(header)),
+		body:    body,
+		etag:    value,
+		expires: time.Now().Add(c.ttl),
+		public:  public,
+	}
+
+	if tags, ok := ctx.Values().Get(cacheTagsKey).([]string); ok {
+		entry.tags = tags
+	}
+
+	entry.size = len(body)
+	for name, values := range header {
+		if equalValues(before[name], values) {
+			continue
+		}
+		entry.header[name] = append([]string(nil), values...)
+		for _, v := range values {
+			entry.size += len(name) + len(v)
+		}
+	}
+
+	if entry.size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, found := c.entries[key]; found {
+		c.remove(el)
+	}
+
+	for c.size+entry.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+}
+
+// NOTE: must be called with c.mu locked
+func (c *Cache) remove(el *list.Element) {
+	entry := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// Checks response headers for directives which forbid shared caching.
+func cacheable(header map[string][]string) bool {
+	if _, found := header["Set-Cookie"]; found {
+		return false
+	}
+
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-store", "no-cache", "private":
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Returns true if header values are the same.
+func equalValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks response headers for directives which allow shared caching of response to request with credentials.
+// SEE: https://tools.ietf.org/html/rfc7234#section-3.2
+func cacheablePublicly(header map[string][]string) bool {
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "public" || strings.HasPrefix(directive, "s-maxage") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Checks If-None-Match header value against ETag (weak comparison).
+func matchETag(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || trimETag(candidate) == trimETag(etag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
+
+func tagSpan(ctx iris.Context, hit bool) {
+	if span := opentracing.SpanFromContext(ctx.Request().Context()); span != nil {
+		span.SetTag("cache.hit", hit)
+	}
+}
@@ -0,0 +1,127 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/ont/iris-related/requestid/v12"
+)
+
+// Returns app with cached route which counts calls of handler.
+func newCacheApp(t *testing.T, cacheControl string, opts ...CacheOption) (*iris.Application, *int) {
+	calls := 0
+
+	app := iris.New()
+	app.Get("/profile", NewCache(opts...).Handler, func(ctx iris.Context) {
+		calls++
+		if cacheControl != "" {
+			ctx.Header("Cache-Control", cacheControl)
+		}
+		ctx.WriteString("hello " + ctx.GetHeader("Cookie"))
+	})
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	return app, &calls
+}
+
+func get(app *iris.Application, header http.Header) string {
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w.Body.String()
+}
+
+func TestCacheSkipsRequestsWithCredentials(t *testing.T) {
+	cases := []struct {
+		name   string
+		header http.Header
+	}{
+		{"cookie", http.Header{"Cookie": {"session=a"}}},
+		{"authorization", http.Header{"Authorization": {"Bearer a"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			app, calls := newCacheApp(t, "")
+
+			get(app, c.header)
+			get(app, c.header)
+			if *calls != 2 {
+				t.Fatalf("expected response with credentials not to be cached, handler is called %d times", *calls)
+			}
+
+			// NOTE: anonymous response isn't served for requests with credentials
+			get(app, nil)
+			get(app, c.header)
+			if *calls != 4 {
+				t.Fatalf("expected anonymous response not to be shared, handler is called %d times", *calls)
+			}
+
+			get(app, nil)
+			if *calls != 4 {
+				t.Fatalf("expected anonymous response to be cached, handler is called %d times", *calls)
+			}
+		})
+	}
+}
+
+func TestCacheSharesPublicResponses(t *testing.T) {
+	for _, cacheControl := range []string{"public, max-age=60", "s-maxage=60"} {
+		app, calls := newCacheApp(t, cacheControl)
+
+		cookie := http.Header{"Cookie": {"session=a"}}
+		get(app, cookie)
+		if body := get(app, http.Header{"Cookie": {"session=b"}}); body != "hello session=a" || *calls != 1 {
+			t.Fatalf("expected %q response to be shared, got %q after %d calls", cacheControl, body, *calls)
+		}
+	}
+}
+
+func TestCacheVariesByCredentials(t *testing.T) {
+	app, calls := newCacheApp(t, "", WithVary("Cookie"))
+
+	a := http.Header{"Cookie": {"session=a"}}
+	b := http.Header{"Cookie": {"session=b"}}
+
+	get(app, a)
+	get(app, b)
+	if body := get(app, a); body != "hello session=a" || *calls != 2 {
+		t.Fatalf("expected responses to be cached per cookie, got %q after %d calls", body, *calls)
+	}
+}
+
+func TestCacheKeepsHeadersOfPreviousMiddlewares(t *testing.T) {
+	app := iris.New()
+	app.Use(requestid.Middleware)
+	app.Get("/users", NewCache().Handler, func(ctx iris.Context) {
+		ctx.Header("X-Handler", "yes")
+		ctx.WriteString("users")
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		if w.Header().Get("X-Handler") != "yes" || w.Body.String() != "users" {
+			t.Fatalf("unexpected response %v %q", w.Header(), w.Body.String())
+		}
+
+		id := w.Header().Get("X-Request-Id")
+		if values := w.Header()["X-Request-Id"]; len(values) != 1 || id == "" || seen[id] {
+			t.Fatalf("expected own request-id for each response, got %v", values)
+		}
+		seen[id] = true
+	}
+}
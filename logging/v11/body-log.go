@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/kataras/iris/context"
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+type bodyLogOptions struct {
+	maxSize       int
+	level         logrus.Level
+	routes        map[string]bool
+	contentTypes  []string
+	sampleRate    float64
+	redactFields  map[string]bool
+	redactHeaders map[string]bool
+	reFields      *regexp.Regexp
+}
+
+type BodyLogOption func(o *bodyLogOptions)
+
+// Sets max size of logged body, the rest is truncated (default is 4Kb).
+func WithMaxBodySize(size int) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		o.maxSize = size
+	}
+}
+
+// Sets level of body log entries (default is debug).
+func WithBodyLevel(level logrus.Level) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		o.level = level
+	}
+}
+
+// Logs bodies only for given route templates.
+// For example: logging.WithBodyRoutes("/users/{id:uint64}")
+func WithBodyRoutes(routes ...string) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		for _, route := range routes {
+			o.routes[route] = true
+		}
+	}
+}
+
+// Logs bodies only when request or response has one of content types.
+// For example: logging.WithBodyContentTypes("application/json")
+func WithBodyContentTypes(types ...string) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		o.contentTypes = append(o.contentTypes, types...)
+	}
+}
+
+// Logs bodies only for given fraction (0..1) of requests (default is 1).
+func WithBodySampling(rate float64) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		o.sampleRate = rate
+	}
+}
+
+// Replaces values of JSON fields with given names (at any depth) in logged bodies.
+func WithRedactFields(names ...string) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		for _, name := range names {
+			o.redactFields[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// Replaces values of given headers in logged headers
+// (Authorization, Cookie, Set-Cookie and X-Api-Key are always redacted).
+func WithRedactHeaders(names ...string) BodyLogOption {
+	return func(o *bodyLogOptions) {
+		for _, name := range names {
+			o.redactHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// Generates middleware which logs request and response bodies and headers via request logger.
+// Must be registered after Middleware to log with request's logger.
+// Usage: app.Use(logging.BodyLog(logging.WithBodyRoutes("/callback"), logging.WithRedactFields("password")))
+func BodyLog(opts ...BodyLogOption) context.Handler {
+	o := &bodyLogOptions{
+		maxSize:      4 << 10,
+		level:        logrus.DebugLevel,
+		routes:       make(map[string]bool),
+		sampleRate:   1,
+		redactFields: make(map[string]bool),
+		redactHeaders: map[string]bool{
+			"Authorization": true,
+			"Cookie":        true,
+			"Set-Cookie":    true,
+			"X-Api-Key":     true,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if len(o.redactFields) > 0 {
+		names := make([]string, 0, len(o.redactFields))
+		for name := range o.redactFields {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+		// NOTE: used for truncated bodies which can't be parsed as JSON
+		o.reFields = regexp.MustCompile(`(?i)("(?:` + strings.Join(names, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`)
+	}
+
+	return func(ctx context.Context) {
+		// NOTE: level of request logger may be raised for debug requests (see WithDebugHeader)
+		entry := Get(ctx)
+		if !o.selected(ctx) || !entry.Logger.IsLevelEnabled(o.level) {
+			ctx.Next()
+			return
+		}
+
+		requestBody, requestTruncated := o.captureRequest(ctx)
+
+		ctx.Record()
+		ctx.Next()
+
+		// NOTE: redaction parses JSON bodies, so it is skipped for bodies which aren't logged
+		if !o.matchContentType(ctx) {
+			return
+		}
+
+		// NOTE: whole response body is available, so it is redacted before truncating
+		responseBody := o.redact(ctx.Recorder().Body())
+		responseTruncated := len(responseBody) > o.maxSize
+		if responseTruncated {
+			responseBody = responseBody[:o.maxSize]
+		}
+
+		entry.WithFields(logrus.Fields{
+			"request_headers":         o.headers(ctx.Request().Header),
+			"request_body":            o.redact(requestBody),
+			"request_body_truncated":  requestTruncated,
+			"response_headers":        o.headers(ctx.ResponseWriter().Header()),
+			"response_body":           responseBody,
+			"response_body_truncated": responseTruncated,
+		}).Log(o.level, "request and response bodies")
+	}
+}
+
+func (o *bodyLogOptions) selected(ctx context.Context) bool {
+	if len(o.routes) > 0 {
+		route := ctx.GetCurrentRoute()
+		if route == nil || !o.routes[route.Path()] {
+			return false
+		}
+	}
+
+	return o.sampleRate >= 1 || rand.Float64() < o.sampleRate
+}
+
+func (o *bodyLogOptions) matchContentType(ctx context.Context) bool {
+	if len(o.contentTypes) == 0 {
+		return true
+	}
+
+	request := ctx.GetHeader("Content-Type")
+	response := ctx.ResponseWriter().Header().Get("Content-Type")
+
+	for _, t := range o.contentTypes {
+		if strings.HasPrefix(request, t) || strings.HasPrefix(response, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reads up to maxSize bytes of request body and puts them back for next handlers.
+func (o *bodyLogOptions) captureRequest(ctx context.Context) ([]byte, bool) {
+	r := ctx.Request()
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+
+	captured, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(o.maxSize)+1))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(captured), r.Body), r.Body}
+	if err != nil {
+		return nil, false
+	}
+
+	if len(captured) > o.maxSize {
+		return captured[:o.maxSize], true
+	}
+	return captured, false
+}
+
+func (o *bodyLogOptions) headers(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if o.redactHeaders[http.CanonicalHeaderKey(name)] {
+			out[name] = redacted
+		} else {
+			out[name] = strings.Join(values, ", ")
+		}
+	}
+	return out
+}
+
+func (o *bodyLogOptions) redact(body []byte) string {
+	if len(o.redactFields) == 0 || len(body) == 0 {
+		return string(body)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redactedBody, err := json.Marshal(o.redactValue(value)); err == nil {
+			return string(redactedBody)
+		}
+	}
+
+	return o.reFields.ReplaceAllString(string(body), `${1}"`+redacted+`"`)
+}
+
+func (o *bodyLogOptions) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if o.redactFields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = o.redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = o.redactValue(item)
+		}
+	}
+	return value
+}
+
+// Request body which reads captured part first and closes original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
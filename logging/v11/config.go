@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Configuration of logger output.
+// Usage:
+//
+//	cfg, err := logging.ConfigFromEnv()
+//	...
+//	logger, closer, err := cfg.NewLogger()
+//	...
+//	defer closer.Close()
+//	l := logging.New(logger)
+//	l.Register(app)
+//	app.Use(l.Handler)
+type Config struct {
+	Level  string // logrus level name (default is "info")
+	Format Format // see NewFormatter
+	Output string // "stdout" (default), "stderr", "file" or "syslog"
+
+	File struct {
+		Path        string
+		MaxSize     int64         // rotate when file exceeds size in bytes
+		RotateEvery time.Duration // rotate when file is older than duration
+		MaxBackups  int           // keep only this number of rotated files
+	}
+
+	Syslog struct {
+		Network string // empty for local syslog socket
+		Addr    string
+		Tag     string
+	}
+
+	AsyncBuffer int  // write to output asynchronously with buffer of given size (0 is synchronous)
+	AsyncBlock  bool // block logging when async buffer is full instead of dropping messages
+}
+
+// Reads config from environment variables:
+// LOG_LEVEL, LOG_FORMAT, LOG_OUTPUT,
+// LOG_FILE_PATH, LOG_FILE_MAX_SIZE, LOG_FILE_ROTATE_EVERY, LOG_FILE_MAX_BACKUPS,
+// LOG_SYSLOG_NETWORK, LOG_SYSLOG_ADDR, LOG_SYSLOG_TAG,
+// LOG_ASYNC_BUFFER, LOG_ASYNC_BLOCK.
+func ConfigFromEnv() (*Config, error) {
+	c := &Config{
+		Level:  os.Getenv("LOG_LEVEL"),
+		Format: Format(os.Getenv("LOG_FORMAT")),
+		Output: os.Getenv("LOG_OUTPUT"),
+	}
+
+	c.File.Path = os.Getenv("LOG_FILE_PATH")
+	c.Syslog.Network = os.Getenv("LOG_SYSLOG_NETWORK")
+	c.Syslog.Addr = os.Getenv("LOG_SYSLOG_ADDR")
+	c.Syslog.Tag = os.Getenv("LOG_SYSLOG_TAG")
+
+	var err error
+
+	if e := os.Getenv("LOG_FILE_MAX_SIZE"); e != "" {
+		if c.File.MaxSize, err = strconv.ParseInt(e, 10, 64); err != nil {
+			return nil, fmt.Errorf("cannot parse env var LOG_FILE_MAX_SIZE=%s: %s", e, err)
+		}
+	}
+
+	if e := os.Getenv("LOG_FILE_ROTATE_EVERY"); e != "" {
+		if c.File.RotateEvery, err = time.ParseDuration(e); err != nil {
+			return nil, fmt.Errorf("cannot parse env var LOG_FILE_ROTATE_EVERY=%s: %s", e, err)
+		}
+	}
+
+	if e := os.Getenv("LOG_FILE_MAX_BACKUPS"); e != "" {
+		if c.File.MaxBackups, err = strconv.Atoi(e); err != nil {
+			return nil, fmt.Errorf("cannot parse env var LOG_FILE_MAX_BACKUPS=%s: %s", e, err)
+		}
+	}
+
+	if e := os.Getenv("LOG_ASYNC_BUFFER"); e != "" {
+		if c.AsyncBuffer, err = strconv.Atoi(e); err != nil {
+			return nil, fmt.Errorf("cannot parse env var LOG_ASYNC_BUFFER=%s: %s", e, err)
+		}
+	}
+
+	if e := os.Getenv("LOG_ASYNC_BLOCK"); e != "" {
+		if c.AsyncBlock, err = strconv.ParseBool(e); err != nil {
+			return nil, fmt.Errorf("cannot parse env var LOG_ASYNC_BLOCK=%s: %s", e, err)
+		}
+	}
+
+	return c, nil
+}
+
+// Creates logger from config. Closer flushes and closes logger's output.
+func (c *Config) NewLogger() (*logrus.Logger, io.Closer, error) {
+	logger := logrus.New()
+
+	if c.Level != "" {
+		level, err := logrus.ParseLevel(c.Level)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.SetLevel(level)
+	}
+
+	formatter, err := NewFormatter(c.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.Formatter = formatter
+
+	var out io.Writer
+	closer := closerFunc(func() error { return nil })
+
+	switch c.Output {
+	case "stdout", "":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	case "file":
+		file, err := NewRotatingFile(c.File.Path, c.File.MaxSize, c.File.RotateEvery, c.File.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = file, file.Close
+	case "syslog":
+		closeSyslog, err := addSyslogHook(logger, c.Syslog.Network, c.Syslog.Addr, c.Syslog.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		// NOTE: everything is written by syslog hook
+		out, closer = ioutil.Discard, closeSyslog
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", c.Output)
+	}
+
+	if c.AsyncBuffer > 0 && out != ioutil.Discard {
+		async := NewAsyncWriter(out, c.AsyncBuffer, c.AsyncBlock)
+		prevCloser := closer
+		out, closer = async, func() error {
+			if err := async.Close(); err != nil {
+				return err
+			}
+			return prevCloser()
+		}
+	}
+
+	logger.Out = out
+
+	return logger, closer, nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
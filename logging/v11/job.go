@@ -0,0 +1,44 @@
+package logging
+
+import (
+	gocontext "context"
+
+	"github.com/ont/iris-related/requestid/v11"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Starts background job (worker, cron job, queue consumer): span, correlation id and logger entry.
+// Nested jobs inherit correlation id and trace of the parent job (or request) from ctx.
+// Returned context carries all of them, so it may be passed to client.Client.WithContext and FromContext.
+// Usage:
+//
+//	span, ctx := logging.StartJob(gocontext.Background(), "cleanup")
+//	defer span.Finish()
+//	logging.FromContext(ctx).Info("cleaning up")
+func StartJob(ctx gocontext.Context, name string) (opentracing.Span, gocontext.Context) {
+	requestId, found := requestid.FromContext(ctx)
+	if !found {
+		requestId = requestid.Hex()
+		ctx = requestid.NewContext(ctx, requestId)
+	}
+
+	// NOTE: span is root span unless ctx already has one
+	span, ctx := opentracing.StartSpanFromContext(ctx, name)
+	span.SetTag("job", name).
+		SetTag("request_id", requestId)
+
+	fields := logrus.Fields{
+		"request_id": requestId,
+		"job":        name,
+	}
+	addSpanFields(fields, span)
+
+	entry, found := ctx.Value(entryContextKey{}).(*logrus.Entry)
+	if !found {
+		entry = logrus.NewEntry(std.logger)
+	}
+	entry = entry.WithFields(fields)
+
+	return span, WithLogger(ctx, entry)
+}
@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFilePrunesOnlyBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	unrelated := []string{"app.log.gz", "app.log.lock", "app.log.20200101T000000.000.gz", "other.log.20200101T000000.000"}
+	old := []string{"app.log.20200101T000000.000", "app.log.20200102T000000.000"}
+	for _, name := range append(unrelated, old...) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := NewRotatingFile(path, 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("first\n"))
+	if _, err := f.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	names := listDir(t, dir)
+
+	expected := map[string]bool{"app.log": true}
+	for _, name := range unrelated {
+		expected[name] = true
+	}
+
+	backups := 0
+	for _, name := range names {
+		if expected[name] {
+			delete(expected, name)
+			continue
+		}
+		for _, o := range old {
+			if name == o {
+				t.Fatalf("old backup %s isn't removed", name)
+			}
+		}
+		backups++
+	}
+
+	if len(expected) != 0 {
+		t.Fatalf("files %v are removed, got %v", expected, names)
+	}
+	if backups != 1 {
+		t.Fatalf("expected one backup, got %v", names)
+	}
+}
+
+func TestRotatingFileReopensFileWhenRotationFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(path, 1, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: rename of missing file fails
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"second\n", "third\n"} {
+		if _, err := f.Write([]byte(msg)); err != nil {
+			t.Fatalf("expected writes to go on after failed rotation, got %s", err)
+		}
+	}
+
+	// NOTE: file is reopened after failed rotation and rotated again on the next write
+	names := listDir(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("expected log file and one backup, got %v", names)
+	}
+
+	contents := make([]string, 0, len(names))
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(data))
+	}
+
+	// NOTE: "app.log" sorts before its backups
+	if contents[0] != "third\n" || contents[1] != "second\n" {
+		t.Fatalf("unexpected contents of files %v: %q", names, contents)
+	}
+}
+
+func TestRotatingFileBacksOffWhenRotationFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	attempts := 0
+	rename = func(from, to string) error {
+		attempts++
+		return errors.New("rename is broken")
+	}
+	defer func() { rename = os.Rename }()
+
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"), 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// NOTE: 3rd write exceeds maxSize, next attempt is made only after next 10 bytes (5th write)
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("abc\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts to rotate file, got %d", attempts)
+	}
+}
+
+func TestRotatingFileKeepsBackupsOfTheSameMillisecond(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotating-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewRotatingFile(filepath.Join(dir, "app.log"), 0, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 12; i++ {
+		backup := f.backupPath(at)
+		if err := ioutil.WriteFile(backup, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.Base(backup))
+	}
+
+	if names[0] != "app.log.20200101T000000.000" || names[1] != "app.log.20200101T000000.000.1" {
+		t.Fatalf("expected counter to be added to taken name, got %v", names)
+	}
+
+	// NOTE: counter orders backups of the same millisecond (".10" is newer than ".9")
+	if err := f.prune(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"app.log", "app.log.20200101T000000.000.10", "app.log.20200101T000000.000.11"}
+	if actual := listDir(t, dir); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v to be kept, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Logrus hook which mirrors request-scoped entries into logs of the active opentracing span.
+// Entry is request-scoped when it carries go context with span (see Logger.Handler and FromContext).
+type SpanHook struct {
+	minLevel logrus.Level
+}
+
+// Creates hook for entries with level minLevel and more severe.
+// For example: logrus.AddHook(logging.NewSpanHook(logrus.InfoLevel))
+func NewSpanHook(minLevel logrus.Level) *SpanHook {
+	return &SpanHook{
+		minLevel: minLevel,
+	}
+}
+
+// Installs SpanHook into the logger (see NewSpanHook).
+func WithSpanHook(minLevel logrus.Level) Option {
+	return func(l *Logger) {
+		l.logger.AddHook(NewSpanHook(minLevel))
+	}
+}
+
+func (h *SpanHook) Levels() []logrus.Level {
+	var levels []logrus.Level
+	for _, level := range logrus.AllLevels {
+		if level <= h.minLevel {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+func (h *SpanHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	span := opentracing.SpanFromContext(entry.Context)
+	if span == nil {
+		return nil
+	}
+
+	kv := []interface{}{
+		"event", entry.Message,
+		"level", entry.Level.String(),
+	}
+
+	for k, v := range entry.Data {
+		switch k {
+		case "trace_id", "span_id": // span already knows them
+			continue
+		case logrus.ErrorKey:
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+		}
+		kv = append(kv, k, v)
+	}
+
+	span.LogKV(kv...)
+
+	if entry.Level <= logrus.ErrorLevel {
+		span.SetTag("error", true)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !windows && !nacl && !plan9
+// +build !windows,!nacl,!plan9
+
+package logging
+
+import (
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// Creates logrus hook which sends entries to syslog.
+// Empty network and addr mean local syslog socket (for example /dev/log).
+func NewSyslogHook(network string, addr string, tag string) (*lsyslog.SyslogHook, error) {
+	return lsyslog.NewSyslogHook(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+}
+
+func addSyslogHook(logger *logrus.Logger, network string, addr string, tag string) (func() error, error) {
+	hook, err := NewSyslogHook(network, addr, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.AddHook(hook)
+
+	return hook.Writer.Close, nil
+}
@@ -0,0 +1,14 @@
+//go:build windows || nacl || plan9
+// +build windows nacl plan9
+
+package logging
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+func addSyslogHook(logger *logrus.Logger, network string, addr string, tag string) (func() error, error) {
+	return nil, errors.New("logging: syslog is not supported on this platform")
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/sirupsen/logrus"
+)
+
+type accessLogOptions struct {
+	level      func(status int) logrus.Level
+	skipPaths  []string
+	skip       func(ctx context.Context) bool
+	sampleRate float64
+}
+
+type AccessLogOption func(o *accessLogOptions)
+
+// Sets function which chooses log level by response status
+// (default is error for 5xx, warning for 4xx and info for others).
+func WithLevelByStatus(level func(status int) logrus.Level) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.level = level
+	}
+}
+
+// Disables access log for requests which path starts with one of prefixes.
+// For example: logging.WithSkipPaths("/health", "/metrics")
+func WithSkipPaths(prefixes ...string) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.skipPaths = append(o.skipPaths, prefixes...)
+	}
+}
+
+// Disables access log for requests for which skip returns true.
+func WithSkip(skip func(ctx context.Context) bool) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.skip = skip
+	}
+}
+
+// Logs only given fraction (0..1) of successful (non 4xx/5xx) requests (default is 1).
+func WithSuccessSampling(rate float64) AccessLogOption {
+	return func(o *accessLogOptions) {
+		o.sampleRate = rate
+	}
+}
+
+func defaultLevelByStatus(status int) logrus.Level {
+	switch {
+	case status >= 500:
+		return logrus.ErrorLevel
+	case status >= 400:
+		return logrus.WarnLevel
+	default:
+		return logrus.InfoLevel
+	}
+}
+
+// Generates middleware which writes one log entry per request.
+// Must be registered after Middleware to log with request's logger.
+// Usage: app.Use(logging.AccessLog(logging.WithSkipPaths("/health")))
+func AccessLog(opts ...AccessLogOption) context.Handler {
+	o := &accessLogOptions{
+		level:      defaultLevelByStatus,
+		sampleRate: 1,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(ctx context.Context) {
+		if o.skipped(ctx) {
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		ctx.Next()
+		latency := time.Since(start)
+
+		status := ctx.GetStatusCode()
+		if status < 400 && o.sampleRate < 1 && rand.Float64() >= o.sampleRate {
+			return
+		}
+
+		route := ""
+		if r := ctx.GetCurrentRoute(); r != nil {
+			route = r.Path()
+		}
+
+		Get(ctx).WithFields(logrus.Fields{
+			"method":     ctx.Method(),
+			"route":      route,
+			"path":       ctx.Path(),
+			"status":     status,
+			"latency_ms": float64(latency) / float64(time.Millisecond),
+			"bytes_in":   bytesIn(ctx),
+			"bytes_out":  bytesOut(ctx),
+			"remote_ip":  ctx.RemoteAddr(),
+			"user_agent": ctx.GetHeader("User-Agent"),
+		}).Log(o.level(status), "request served")
+	}
+}
+
+func (o *accessLogOptions) skipped(ctx context.Context) bool {
+	for _, prefix := range o.skipPaths {
+		if strings.HasPrefix(ctx.Path(), prefix) {
+			return true
+		}
+	}
+
+	return o.skip != nil && o.skip(ctx)
+}
+
+func bytesIn(ctx context.Context) int64 {
+	if n := ctx.GetContentLength(); n > 0 {
+		return n
+	}
+	return 0
+}
+
+func bytesOut(ctx context.Context) int {
+	// NOTE: recorder flushes body to client only after all handlers are done
+	if rec, ok := ctx.IsRecording(); ok {
+		return len(rec.Body())
+	}
+
+	if n := ctx.ResponseWriter().Written(); n > 0 {
+		return n
+	}
+	return 0
+}
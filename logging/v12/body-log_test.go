@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// Returns app with body log which responds with given content type and tells whether response was recorded.
+func newBodyLogApp(t *testing.T, level logrus.Level, contentType string, opts ...BodyLogOption) (*iris.Application, *test.Hook, *bool) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(level)
+
+	recorded := false
+
+	app := iris.New()
+	app.Use(New(logger).Handler)
+	app.Use(BodyLog(opts...))
+	app.Post("/users", func(ctx iris.Context) {
+		_, recorded = ctx.IsRecording()
+		ctx.ContentType(contentType)
+		ctx.WriteString(`{"name":"john","password":"secret"}`)
+	})
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	return app, hook, &recorded
+}
+
+func post(app *iris.Application) {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"password":"secret"}`))
+	app.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestBodyLogRedactsFields(t *testing.T) {
+	app, hook, _ := newBodyLogApp(t, logrus.DebugLevel, "application/json", WithRedactFields("password"))
+
+	post(app)
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatalf("expected bodies to be logged")
+	}
+	for _, field := range []string{"request_body", "response_body"} {
+		body := entry.Data[field].(string)
+		if strings.Contains(body, "secret") || !strings.Contains(body, redacted) {
+			t.Fatalf("expected %s to be redacted, got %s", field, body)
+		}
+	}
+}
+
+func TestBodyLogSkipsOtherContentTypes(t *testing.T) {
+	app, hook, _ := newBodyLogApp(t, logrus.DebugLevel, "text/plain",
+		WithRedactFields("password"),
+		WithBodyContentTypes("application/json"),
+	)
+
+	post(app)
+
+	if entry := hook.LastEntry(); entry != nil {
+		t.Fatalf("expected bodies not to be logged, got %v", entry.Data)
+	}
+}
+
+func TestBodyLogSkipsDisabledLevel(t *testing.T) {
+	app, hook, recorded := newBodyLogApp(t, logrus.InfoLevel, "application/json", WithRedactFields("password"))
+
+	post(app)
+
+	if *recorded {
+		t.Fatalf("expected response not to be recorded when level is disabled")
+	}
+	if entry := hook.LastEntry(); entry != nil {
+		t.Fatalf("expected bodies not to be logged, got %v", entry.Data)
+	}
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Name of built-in log output format.
+type Format string
+
+const (
+	FormatText   Format = "text"   // default logrus text format
+	FormatJSON   Format = "json"   // default logrus JSON format
+	FormatLogfmt Format = "logfmt" // key=value pairs without colors
+	FormatECS    Format = "ecs"    // Elastic Common Schema JSON
+	FormatGELF   Format = "gelf"   // Graylog Extended Log Format JSON
+	FormatDev    Format = "dev"    // colored human-readable format for local development
+)
+
+// Returns formatter for built-in format.
+func NewFormatter(format Format) (logrus.Formatter, error) {
+	switch format {
+	case FormatText, "":
+		return &logrus.TextFormatter{}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	case FormatLogfmt:
+		return &logrus.TextFormatter{
+			DisableColors:    true,
+			FullTimestamp:    true,
+			QuoteEmptyFields: true,
+		}, nil
+	case FormatECS:
+		return NewECSFormatter(), nil
+	case FormatGELF:
+		return NewGELFFormatter(), nil
+	case FormatDev:
+		return &logrus.TextFormatter{
+			ForceColors:     true,
+			FullTimestamp:   true,
+			TimestampFormat: "15:04:05.000",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+const ecsVersion = "1.6.0"
+
+// Formatter for Elastic Common Schema.
+// SEE: https://www.elastic.co/guide/en/ecs/current/ecs-base.html
+type ECSFormatter struct {
+	json logrus.JSONFormatter
+}
+
+func NewECSFormatter() *ECSFormatter {
+	return &ECSFormatter{
+		json: logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime:  "@timestamp",
+				logrus.FieldKeyMsg:   "message",
+				logrus.FieldKeyLevel: "log.level",
+				logrus.FieldKeyFunc:  "log.origin.function",
+				logrus.FieldKeyFile:  "log.origin.file.name",
+			},
+		},
+	}
+}
+
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+1)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	data["ecs.version"] = ecsVersion
+
+	return f.json.Format(withData(entry, data))
+}
+
+// Formatter for Graylog Extended Log Format (one JSON message per line).
+// SEE: https://docs.graylog.org/en/3.3/pages/gelf.html#gelf-payload-specification
+type GELFFormatter struct {
+	Host string // defaults to os.Hostname()
+}
+
+func NewGELFFormatter() *GELFFormatter {
+	host, _ := os.Hostname()
+	return &GELFFormatter{
+		Host: host,
+	}
+}
+
+var reGELFField = regexp.MustCompile(`[^\w.\-]`)
+
+func (f *GELFFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	message := map[string]interface{}{
+		"version":       "1.1",
+		"host":          f.Host,
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixNano()) / float64(time.Second),
+		"level":         syslogLevel(entry.Level),
+	}
+
+	if entry.HasCaller() {
+		message["_file"] = entry.Caller.File
+		message["_line"] = entry.Caller.Line
+	}
+
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+
+		// NOTE: "_id" is reserved by GELF
+		key := "_" + reGELFField.ReplaceAllString(k, "_")
+		if key == "_id" {
+			key = "__id"
+		}
+		message[key] = v
+	}
+
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal GELF message: %s", err)
+	}
+
+	return append(bytes, '\n'), nil
+}
+
+// Maps logrus level to syslog severity.
+func syslogLevel(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0 // emergency
+	case logrus.FatalLevel:
+		return 2 // critical
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7 // debug
+	}
+}
+
+func withData(entry *logrus.Entry, data logrus.Fields) *logrus.Entry {
+	return &logrus.Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Caller:  entry.Caller,
+		Message: entry.Message,
+		Buffer:  entry.Buffer,
+		Context: entry.Context,
+	}
+}
@@ -0,0 +1,233 @@
+package logging
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sync"
+
+	"github.com/kataras/iris/v12/context"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/sirupsen/logrus"
+	"github.com/uber/jaeger-client-go"
+)
+
+// Runtime levels of the logger and its package loggers.
+type levels struct {
+	mu        sync.Mutex
+	packages  map[string]*logrus.Logger
+	overrides map[string]logrus.Level
+
+	debugOnce sync.Once
+	debug     *logrus.Logger
+}
+
+// Enables debug output for requests which have header with given value.
+// Empty value means any non-empty value of the header.
+// NOTE: header must be set only by trusted party (strip it on the edge proxy or use secret value).
+// Debug output is written by separate logger with output and formatter of parent logger.
+func WithDebugHeader(name string, value string) Option {
+	return func(l *Logger) {
+		l.debugHeader = name
+		l.debugValue = value
+	}
+}
+
+// Enables debug output for requests with jaeger debug span (see "jaeger-debug-id" header).
+func WithDebugTrace() Option {
+	return func(l *Logger) {
+		l.debugTrace = true
+	}
+}
+
+// Returns logger for package name which level may be changed independently (see LevelHandler).
+// Package logger shares output, formatter and hooks with parent logger (including later changes of them).
+func (l *Logger) Package(name string) *logrus.Logger {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	if pkg, found := l.levels.packages[name]; found {
+		return pkg
+	}
+
+	pkg := l.derive(l.logger.GetLevel())
+	if level, found := l.levels.overrides[name]; found {
+		pkg.SetLevel(level)
+	}
+
+	if l.levels.packages == nil {
+		l.levels.packages = make(map[string]*logrus.Logger)
+	}
+	l.levels.packages[name] = pkg
+
+	return pkg
+}
+
+// Returns logger for package name of default logger (see Logger.Package).
+func Package(name string) *logrus.Logger {
+	return std.Package(name)
+}
+
+type levelsState struct {
+	Level    string            `json:"level,omitempty"`
+	Packages map[string]string `json:"packages,omitempty"`
+}
+
+// Admin handler which shows (GET) or changes (PUT, POST) global and package levels.
+// Request body: {"level": "info", "packages": {"db": "debug", "cache": ""}}, empty level removes package override.
+// Usage: admin.Any("/log-level", l.LevelHandler)
+func (l *Logger) LevelHandler(ctx context.Context) {
+	if ctx.Method() == http.MethodPut || ctx.Method() == http.MethodPost {
+		var state levelsState
+		if err := ctx.ReadJSON(&state); err != nil {
+			ctx.StatusCode(http.StatusBadRequest)
+			ctx.WriteString(err.Error())
+			return
+		}
+
+		if err := l.setLevels(state); err != nil {
+			ctx.StatusCode(http.StatusBadRequest)
+			ctx.WriteString(err.Error())
+			return
+		}
+	}
+
+	ctx.JSON(l.getLevels())
+}
+
+func (l *Logger) getLevels() levelsState {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	state := levelsState{
+		Level:    l.logger.GetLevel().String(),
+		Packages: make(map[string]string),
+	}
+
+	for name, level := range l.levels.overrides {
+		state.Packages[name] = level.String()
+	}
+
+	return state
+}
+
+func (l *Logger) setLevels(state levelsState) error {
+	// NOTE: parse everything before changing anything
+	var level logrus.Level
+	var err error
+
+	if state.Level != "" {
+		if level, err = logrus.ParseLevel(state.Level); err != nil {
+			return err
+		}
+	}
+
+	overrides := make(map[string]*logrus.Level)
+	for name, value := range state.Packages {
+		if value == "" {
+			overrides[name] = nil
+			continue
+		}
+
+		pkgLevel, err := logrus.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		overrides[name] = &pkgLevel
+	}
+
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	if state.Level != "" {
+		l.logger.SetLevel(level)
+	}
+
+	if l.levels.overrides == nil {
+		l.levels.overrides = make(map[string]logrus.Level)
+	}
+
+	for name, pkgLevel := range overrides {
+		if pkgLevel == nil {
+			delete(l.levels.overrides, name)
+		} else {
+			l.levels.overrides[name] = *pkgLevel
+		}
+	}
+
+	// packages without override follow global level
+	for name, pkg := range l.levels.packages {
+		if pkgLevel, found := l.levels.overrides[name]; found {
+			pkg.SetLevel(pkgLevel)
+		} else {
+			pkg.SetLevel(l.logger.GetLevel())
+		}
+	}
+
+	return nil
+}
+
+// Checks whether debug output was requested for current request.
+func (l *Logger) debugRequested(ctx context.Context) bool {
+	if l.debugHeader != "" {
+		if value := ctx.GetHeader(l.debugHeader); value != "" {
+			if l.debugValue == "" || subtle.ConstantTimeCompare([]byte(value), []byte(l.debugValue)) == 1 {
+				return true
+			}
+		}
+	}
+
+	if l.debugTrace {
+		if span := opentracing.SpanFromContext(ctx.Request().Context()); span != nil {
+			if sc, ok := span.Context().(jaeger.SpanContext); ok && sc.IsDebug() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns logger with debug level which shares everything else with parent logger.
+func (l *Logger) debugLogger() *logrus.Logger {
+	l.levels.debugOnce.Do(func() {
+		l.levels.debug = l.derive(logrus.DebugLevel)
+	})
+	return l.levels.debug
+}
+
+// Returns logger with given level and the rest taken from parent logger.
+// Output and formatter of parent are looked up on each entry, so derived logger
+// follows SetOutput/SetFormatter calls made after it was created.
+// Logrus doesn't expose lock of the logger, so writes of parent and derived loggers
+// are serialized only by output itself (os.File, RotatingFile and AsyncWriter are safe).
+func (l *Logger) derive(level logrus.Level) *logrus.Logger {
+	return &logrus.Logger{
+		Out:          parentOutput{l.logger},
+		Hooks:        l.logger.Hooks,
+		Formatter:    parentFormatter{l.logger},
+		ReportCaller: l.logger.ReportCaller,
+		ExitFunc:     l.logger.ExitFunc,
+		Level:        level,
+	}
+}
+
+// Writes to current output of parent logger.
+type parentOutput struct {
+	parent *logrus.Logger
+}
+
+func (o parentOutput) Write(p []byte) (int, error) {
+	return o.parent.Out.Write(p)
+}
+
+// Formats entries by current formatter of parent logger.
+type parentFormatter struct {
+	parent *logrus.Logger
+}
+
+func (f parentFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// NOTE: formatters look at entry's logger (TextFormatter checks whether its output is terminal)
+	e := *entry
+	e.Logger = f.parent
+	return f.parent.Formatter.Format(&e)
+}
@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPackageLoggerFollowsParentOutputAndFormatter(t *testing.T) {
+	logger := logrus.New()
+	l := New(logger)
+
+	pkg := l.Package("db")
+	debug := l.debugLogger()
+
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	pkg.Info("from package")
+	debug.Debug("from debug")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 entries in new output of parent, got %q", out.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "{") {
+			t.Fatalf("expected entry formatted by new formatter of parent, got %q", line)
+		}
+	}
+}
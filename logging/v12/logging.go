@@ -3,19 +3,186 @@ package logging
 import (
 	"crypto/rand"
 	"fmt"
+	"sync"
 
+	gocontext "context"
+
+	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/context"
 	"github.com/ont/iris-related/requestid/v12"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/sirupsen/logrus"
+	"github.com/uber/jaeger-client-go"
 )
 
-var (
-	logger *logrus.Logger
+// Key of request logger in iris context values (read it by Get/Lookup).
+// NOTE: iris values are keyed by plain strings, so key is namespaced by package name.
+const loggerKey = "logging.logger"
+
+// Logging middleware which owns its logrus logger.
+// Usage:
+//
+//	l := logging.New(logrus.New(), logging.WithFormatter(&logrus.JSONFormatter{}))
+//	l.Register(app)
+//	app.Use(requestid.Middleware)
+//	app.Use(l.Handler)
+//
+// Handler takes request-id and span of the request, so it goes after requestid and tracing middlewares
+// (UseGlobal handlers run before Use ones, so don't mix them up).
+type Logger struct {
+	logger     *logrus.Logger
+	fields     Field
+	extractors []extractor
+
+	levels      levels
+	debugHeader string
+	debugValue  string
+	debugTrace  bool
+}
+
+// Set of optional fields of request logger entry (request_id is always present).
+type Field int
+
+const (
+	FieldTrace    Field = 1 << iota // trace_id and span_id of active jaeger span
+	FieldMethod                     // http method
+	FieldRoute                      // route template (for example "/users/{id:uint64}")
+	FieldClientIP                   // client ip
+
+	DefaultFields = FieldTrace | FieldMethod | FieldRoute | FieldClientIP
 )
 
+type extractor struct {
+	field   string
+	extract func(ctx context.Context) string
+}
+
+type Option func(l *Logger)
+
+// Sets formatter of the logger.
+func WithFormatter(formatter logrus.Formatter) Option {
+	return func(l *Logger) {
+		l.logger.Formatter = formatter
+	}
+}
+
+// Sets optional fields of request logger entry (default is DefaultFields).
+// For example: logging.WithFields(logging.FieldTrace | logging.FieldRoute)
+func WithFields(fields Field) Option {
+	return func(l *Logger) {
+		l.fields = fields
+	}
+}
+
+// Adds field to request logger entry with value returned by extract (empty values are omitted).
+// For example: logging.WithExtractor("user_id", func(ctx context.Context) string { return ctx.GetHeader("X-User-Id") })
+func WithExtractor(field string, extract func(ctx context.Context) string) Option {
+	return func(l *Logger) {
+		l.extractors = append(l.extractors, extractor{field, extract})
+	}
+}
+
+// Logger used by package level functions and as fallback when there is no request logger.
+var std = New(logrus.New())
+
+// Creates logging middleware around logger.
+func New(logger *logrus.Logger, opts ...Option) *Logger {
+	l := &Logger{
+		logger: logger,
+		fields: DefaultFields,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Installs logger into the app, Handler should be added by caller after requestid and tracing middlewares.
+func (l *Logger) Register(app *iris.Application) {
+	app.Logger().Install(l.logger)
+}
+
+// Returns underlying logrus logger.
+func (l *Logger) Logrus() *logrus.Logger {
+	return l.logger
+}
+
+// Middleware handler which sets up logger entry with preconfigured request-id output.
+// Use Register to install logger into the app too.
+// NOTE: add it after requestid and opentracing middlewares to get request-id and trace fields.
+func (l *Logger) Handler(ctx context.Context) {
+	logger := l.logger
+	if !logger.IsLevelEnabled(logrus.DebugLevel) && l.debugRequested(ctx) {
+		logger = l.debugLogger()
+	}
+
+	entry := logger.WithFields(l.requestFields(ctx))
+
+	// NOTE: go context of the entry is used by SpanHook to find active span
+	entry = entry.WithContext(ctx.Request().Context())
+
+	// request-id taken from trace headers equals to trace-id, so mark it to join logs with traces
+	switch source := requestid.GetSource(ctx); source {
+	case requestid.SourceTraceparent, requestid.SourceJaeger:
+		entry = entry.WithField("request_id_source", string(source))
+		entry.Debugf("request-id is taken from trace-id of %s header", source)
+	}
+
+	ctx.Values().Set(loggerKey, entry)
+	ctx.ResetRequest(ctx.Request().WithContext(WithLogger(ctx.Request().Context(), entry)))
+
+	ctx.Next() // all ok, call other middlewares
+}
+
+func (l *Logger) requestFields(ctx context.Context) logrus.Fields {
+	fields := logrus.Fields{
+		"request_id": requestid.Get(ctx),
+	}
+
+	if l.fields&FieldTrace != 0 {
+		addSpanFields(fields, opentracing.SpanFromContext(ctx.Request().Context()))
+	}
+
+	if l.fields&FieldMethod != 0 {
+		fields["method"] = ctx.Method()
+	}
+
+	if l.fields&FieldRoute != 0 {
+		if route := ctx.GetCurrentRoute(); route != nil {
+			fields["route"] = route.Path()
+		}
+	}
+
+	if l.fields&FieldClientIP != 0 {
+		fields["client_ip"] = ctx.RemoteAddr()
+	}
+
+	for _, e := range l.extractors {
+		if value := e.extract(ctx); value != "" {
+			fields[e.field] = value
+		}
+	}
+
+	return fields
+}
+
+// Adds trace_id and span_id of jaeger span (if any) to fields.
+func addSpanFields(fields logrus.Fields, span opentracing.Span) {
+	if span == nil {
+		return
+	}
+
+	if sc, ok := span.Context().(jaeger.SpanContext); ok {
+		fields["trace_id"] = sc.TraceID().String()
+		fields["span_id"] = sc.SpanID().String()
+	}
+}
+
 // Returns logger with random request-id pregenerated
 func Generate() *logrus.Entry {
-	return logger.WithField("request_id", randToken())
+	return std.logger.WithField("request_id", randToken())
 }
 
 // SEE: https://stackoverflow.com/a/25431798
@@ -25,45 +192,69 @@ func randToken() string {
 	return fmt.Sprintf("%x", b)
 }
 
+// Returns logger from context and false if Middleware wasn't installed.
+func Lookup(ctx context.Context) (*logrus.Entry, bool) {
+	entry, ok := ctx.Values().Get(loggerKey).(*logrus.Entry)
+	return entry, ok
+}
+
 // Returns logger from context.
+// Falls back to global logger with request-id (if any) when Middleware wasn't installed.
 func Get(ctx context.Context) *logrus.Entry {
-	return ctx.Values().Get("logger").(*logrus.Entry)
+	if entry, ok := Lookup(ctx); ok {
+		return entry
+	}
+	return std.logger.WithField("request_id", requestid.Get(ctx)).
+		WithContext(ctx.Request().Context())
+}
+
+// Key of logger entry in go context.
+type entryContextKey struct{}
+
+// Returns copy of go context with logger entry attached.
+func WithLogger(ctx gocontext.Context, entry *logrus.Entry) gocontext.Context {
+	return gocontext.WithValue(ctx, entryContextKey{}, entry)
+}
+
+// Returns logger entry from go context (see WithLogger, Logger.Handler and StartJob).
+// Falls back to global logger with request-id taken from go context (see requestid.NewContext).
+// Useful for code below handlers layer which has no access to iris context.
+func FromContext(ctx gocontext.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(entryContextKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	if requestId, ok := requestid.FromContext(ctx); ok {
+		return std.logger.WithField("request_id", requestId).WithContext(ctx)
+	}
+	return std.logger.WithContext(ctx)
 }
 
 // Logs fatal error and stops program
 func Fatalf(message string, args ...interface{}) {
-	logger.Fatalf(message, args...)
+	std.logger.Fatalf(message, args...)
 }
 
 func Infof(message string, args ...interface{}) {
-	logger.Infof(message, args...)
+	std.logger.Infof(message, args...)
 }
 
 func Errorf(message string, args ...interface{}) {
-	logger.Errorf(message, args...)
+	std.logger.Errorf(message, args...)
 }
 
-// Prepares logger and generates middleware handler.
+// Prepares global logger and generates middleware handler.
 // Usage: app.Use(logging.Middleware(logrus.JSONFormatter{}))
+//
+// Deprecated: use New(logger, opts...) with its Register and Handler instead.
 func Middleware(formatter logrus.Formatter) context.Handler {
-	installed := false
-	logger.Formatter = formatter
+	var once sync.Once
+	WithFormatter(formatter)(std)
 
 	return func(ctx context.Context) {
-		// TODO: refactor "installed" check
-		if !installed {
-			ctx.Application().Logger().Install(logger)
-			installed = true
-		}
-
-		// The main purpose of this middleware: setup logger entry with preconfigured request-id output
-		entry := logger.WithField("request_id", requestid.Get(ctx))
-		ctx.Values().Set("logger", entry)
+		once.Do(func() {
+			ctx.Application().Logger().Install(std.logger)
+		})
 
-		ctx.Next() // all ok, call other middlewares
+		std.Handler(ctx)
 	}
 }
-
-func init() {
-	logger = logrus.New()
-}
@@ -0,0 +1,325 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errClosed = errors.New("logging: writer is closed")
+
+// Layout of timestamp suffix of rotated files.
+const backupLayout = "20060102T150405.000"
+
+// Renames files on rotation (replaced in tests).
+var rename = os.Rename
+
+// Writer which writes to underlying writer from background goroutine.
+// Use it as logger output to make slow outputs (files, network) non-blocking.
+type AsyncWriter struct {
+	out   io.Writer
+	block bool
+
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	dropped uint64
+}
+
+// Creates writer with buffer of bufferSize messages.
+// When buffer is full Write blocks if block is true or drops message otherwise (see Dropped).
+func NewAsyncWriter(out io.Writer, bufferSize int, block bool) *AsyncWriter {
+	w := &AsyncWriter{
+		out:   out,
+		block: block,
+		queue: make(chan []byte, bufferSize),
+		done:  make(chan struct{}),
+	}
+
+	go w.loop()
+
+	return w
+}
+
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, errClosed
+	}
+
+	// NOTE: logrus reuses buffers, so message must be copied
+	msg := make([]byte, len(p))
+	copy(msg, p)
+
+	if w.block {
+		w.queue <- msg
+		return len(p), nil
+	}
+
+	select {
+	case w.queue <- msg:
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+
+	return len(p), nil
+}
+
+// Returns number of messages dropped because of full buffer.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flushes buffered messages. Underlying writer stays open.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+
+	return nil
+}
+
+func (w *AsyncWriter) loop() {
+	defer close(w.done)
+
+	for msg := range w.queue {
+		if _, err := w.out.Write(msg); err != nil {
+			fmt.Fprintf(os.Stderr, "logging: can't write log message: %s\n", err)
+		}
+	}
+}
+
+// File writer which rotates file by size and/or time.
+// Rotated files are renamed to "<path>.<timestamp>" ("<path>.<timestamp>.<n>" when the name is taken),
+// only maxBackups newest of them are kept.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	sizeLimit int64 // size which triggers rotation, it is raised when rotation fails
+	openedAt  time.Time
+}
+
+// Opens file for appending. Zero maxSize, interval or maxBackups disables corresponding limit.
+func NewRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, errClosed
+	}
+
+	if f.needsRotation(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+func (f *RotatingFile) needsRotation(n int) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(n) > f.sizeLimit {
+		return true
+	}
+	return f.interval > 0 && time.Since(f.openedAt) >= f.interval
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.sizeLimit = f.maxSize
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+// NOTE: must be called with f.mu locked
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if err := rename(f.path, f.backupPath(time.Now())); err != nil {
+		// NOTE: writing goes on into the original file, otherwise all next writes would fail.
+		//       Rotation is retried after the next maxSize bytes or interval (open resets its start),
+		//       not on each write.
+		fmt.Fprintf(os.Stderr, "logging: can't rotate log file: %s\n", err)
+		if err := f.open(); err != nil {
+			return err
+		}
+		f.sizeLimit = f.size + f.maxSize
+		return nil
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.prune()
+}
+
+// Removes the oldest backups above maxBackups.
+func (f *RotatingFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	for _, backup := range backups[:len(backups)-f.maxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns path for backup of file rotated at given time.
+// Counter is added when backup with the same timestamp exists, so rotations within
+// the same millisecond don't overwrite each other.
+func (f *RotatingFile) backupPath(at time.Time) string {
+	base := f.path + "." + at.Format(backupLayout)
+
+	backup := base
+	for n := 1; ; n++ {
+		// NOTE: other errors than missing file are left to rename
+		if _, err := os.Lstat(backup); err != nil {
+			return backup
+		}
+		backup = base + "." + strconv.Itoa(n)
+	}
+}
+
+// Returns paths of rotated files from the oldest to the newest,
+// other files with the same prefix (for example "app.log.gz") are skipped.
+func (f *RotatingFile) backups() ([]string, error) {
+	dir, name := filepath.Split(f.path)
+
+	files, err := ioutil.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		path      string
+		timestamp string
+		n         int
+	}
+
+	var found []backup
+	for _, file := range files {
+		suffix := strings.TrimPrefix(file.Name(), name+".")
+		if suffix == file.Name() || file.IsDir() || len(suffix) < len(backupLayout) {
+			continue
+		}
+
+		timestamp, counter := suffix[:len(backupLayout)], suffix[len(backupLayout):]
+		if _, err := time.Parse(backupLayout, timestamp); err != nil {
+			continue
+		}
+
+		n := 0
+		if counter != "" {
+			if counter[0] != '.' {
+				continue
+			}
+			if n, err = strconv.Atoi(counter[1:]); err != nil || n <= 0 {
+				continue
+			}
+		}
+
+		found = append(found, backup{filepath.Join(dir, file.Name()), timestamp, n})
+	}
+
+	// NOTE: timestamp suffix sorts in chronological order, counter orders backups of the same millisecond
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].timestamp != found[j].timestamp {
+			return found[i].timestamp < found[j].timestamp
+		}
+		return found[i].n < found[j].n
+	})
+
+	backups := make([]string, 0, len(found))
+	for _, b := range found {
+		backups = append(backups, b.path)
+	}
+
+	return backups, nil
+}
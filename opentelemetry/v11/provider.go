@@ -0,0 +1,34 @@
+package opentelemetry
+
+import (
+	gocontext "context"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Creates provider which exports spans in batches via OTLP over HTTP.
+// Exporter also reads OTEL_EXPORTER_OTLP_* env vars (for example OTEL_EXPORTER_OTLP_ENDPOINT).
+// SEE: https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/protocol/exporter.md
+func NewProvider(ctx gocontext.Context, serviceName string, opts ...otlptracehttp.Option) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceNameKey.String(serviceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
@@ -0,0 +1,228 @@
+package opentelemetry
+
+import (
+	"fmt"
+	"net/http"
+
+	gocontext "context"
+
+	"github.com/kataras/iris/v12/context"
+	opentracing "github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
+	otelbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of instrumentation library which is reported with each span.
+const instrumentationName = "github.com/ont/iris-related/opentelemetry"
+
+// Key of trace context in iris context values (read it by GetContextFrom/LookupContextFrom).
+// NOTE: iris values are keyed by plain strings, so key is namespaced by package name.
+const traceCtxKey = "opentelemetry.trace-ctx"
+
+// Returns span name for request.
+type SpanNamer func(ctx context.Context) string
+
+// Tracing middleware built on OpenTelemetry.
+// Usage:
+//
+//	provider, err := opentelemetry.NewProvider(ctx, "my-service", otlptracehttp.WithInsecure())
+//	...
+//	t := opentelemetry.New(provider, opentelemetry.WithOpentracingBridge())
+//	defer t.Shutdown(ctx)
+//	app.UseGlobal(t.Handler)
+type Tracing struct {
+	provider   trace.TracerProvider
+	shutdown   func(ctx gocontext.Context) error
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	global     bool
+	bridge     bool
+	serverName string
+	spanName   SpanNamer
+}
+
+type Option func(t *Tracing)
+
+// Sets propagator of trace context (default is W3C Trace Context and Baggage).
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *Tracing) {
+		t.propagator = propagator
+	}
+}
+
+// Sets whether provider and propagator should be installed as OpenTelemetry globals (default is true).
+func WithGlobalProvider(global bool) Option {
+	return func(t *Tracing) {
+		t.global = global
+	}
+}
+
+// Installs opentracing global tracer which translates opentracing calls into OpenTelemetry spans.
+// Existing opentracing.StartSpanFromContext callers get children of the request span.
+func WithOpentracingBridge() Option {
+	return func(t *Tracing) {
+		t.bridge = true
+	}
+}
+
+// Sets name of the server which is reported as http.server_name attribute.
+func WithServerName(name string) Option {
+	return func(t *Tracing) {
+		t.serverName = name
+	}
+}
+
+// Sets function which returns span name for request (default is DefaultSpanName).
+func WithSpanNamer(namer SpanNamer) Option {
+	return func(t *Tracing) {
+		t.spanName = namer
+	}
+}
+
+// Creates tracing middleware.
+// Nil provider means OpenTelemetry global provider.
+func New(provider trace.TracerProvider, opts ...Option) *Tracing {
+	t := &Tracing{
+		provider: provider,
+		global:   true,
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.provider == nil {
+		t.provider = otel.GetTracerProvider()
+	}
+
+	if t.spanName == nil {
+		t.spanName = DefaultSpanName
+	}
+
+	// NOTE: bridge wraps provider, so shutdown is taken from original one
+	if provider, ok := t.provider.(interface {
+		Shutdown(ctx gocontext.Context) error
+	}); ok {
+		t.shutdown = provider.Shutdown
+	}
+
+	if t.bridge {
+		bridge, wrapper := otelbridge.NewTracerPair(t.provider.Tracer(instrumentationName))
+		bridge.SetTextMapPropagator(t.propagator)
+		opentracing.SetGlobalTracer(bridge)
+
+		// NOTE: wrapped tracer puts bridge span into context of each started span,
+		//       so opentracing.SpanFromContext returns current OpenTelemetry span
+		t.provider = wrapper
+	}
+
+	t.tracer = t.provider.Tracer(instrumentationName)
+
+	if t.global {
+		otel.SetTracerProvider(t.provider)
+		otel.SetTextMapPropagator(t.propagator)
+	}
+
+	return t
+}
+
+// Returns tracer which is used for spans of the middleware.
+func (t *Tracing) Tracer() trace.Tracer {
+	return t.tracer
+}
+
+// Returns propagator which is used for extracting and injecting trace context.
+func (t *Tracing) Propagator() propagation.TextMapPropagator {
+	return t.propagator
+}
+
+// Flushes spans and shuts provider down (if provider supports it). Call it on shutdown.
+func (t *Tracing) Shutdown(ctx gocontext.Context) error {
+	if t.shutdown == nil {
+		return nil
+	}
+	return t.shutdown(ctx)
+}
+
+// Returns trace context from iris context and false if middleware wasn't installed.
+func LookupContextFrom(ctx context.Context) (gocontext.Context, bool) {
+	traceCtx, ok := ctx.Values().Get(traceCtxKey).(gocontext.Context)
+	return traceCtx, ok
+}
+
+// Returns trace context from iris context.
+// Falls back to context of the request when middleware wasn't installed.
+func GetContextFrom(ctx context.Context) gocontext.Context {
+	if traceCtx, ok := LookupContextFrom(ctx); ok {
+		return traceCtx
+	}
+	return ctx.Request().Context()
+}
+
+// Starts child span of the request span using global provider.
+func StartSpanFromContext(ctx context.Context, spanName string) (trace.Span, gocontext.Context) {
+	traceCtx, span := otel.Tracer(instrumentationName).Start(GetContextFrom(ctx), spanName)
+	return span, traceCtx
+}
+
+// Returns span name with route template (for example "HTTP request (GET: /users/{id:uint64})").
+// Falls back to method only when request doesn't match any route.
+func DefaultSpanName(ctx context.Context) string {
+	if route := ctx.GetCurrentRoute(); route != nil {
+		return fmt.Sprintf("HTTP request (%s: %s)", ctx.Method(), route.Path())
+	}
+	return fmt.Sprintf("HTTP request (%s)", ctx.Method())
+}
+
+// Middleware handler which starts server span for each request.
+func (t *Tracing) Handler(ctx context.Context) {
+	req := ctx.Request()
+
+	traceCtx := t.propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	var route string
+	if r := ctx.GetCurrentRoute(); r != nil {
+		route = r.Path()
+	}
+
+	traceCtx, span := t.tracer.Start(
+		traceCtx,
+		t.spanName(ctx),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(t.serverName, route, req)...),
+		trace.WithAttributes(semconv.NetAttributesFromHTTPRequest("tcp", req)...),
+	)
+
+	ctx.Values().Set(traceCtxKey, traceCtx)
+
+	// NOTE: other middlewares take span by trace.SpanFromContext(ctx.Request().Context())
+	ctx.ResetRequest(req.WithContext(traceCtx))
+
+	defer func() {
+		if r := recover(); r != nil {
+			span.RecordError(fmt.Errorf("panic: %v", r))
+			span.SetStatus(codes.Error, "panic")
+			span.End()
+			panic(r)
+		}
+
+		status := ctx.GetStatusCode()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+
+		// NOTE: 4xx are errors of client, so server span stays unset for them
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}()
+
+	ctx.Next()
+}
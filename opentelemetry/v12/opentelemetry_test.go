@@ -0,0 +1,194 @@
+package opentelemetry
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	gocontext "context"
+
+	"github.com/kataras/iris/v12"
+	opentracing "github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	incomingTraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingSpanId  = "00f067aa0ba902b7"
+)
+
+// In-process OTLP/HTTP receiver which keeps exported spans by name.
+type collector struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	spans map[string]*tracepb.Span
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{
+		spans: make(map[string]*tracepb.Span),
+	}
+
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("can't read export request: %s", err)
+			return
+		}
+
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("can't parse export request: %s", err)
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				for _, span := range ils.Spans {
+					c.spans[span.Name] = span
+				}
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+func (c *collector) span(t *testing.T, name string) *tracepb.Span {
+	t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	span, found := c.spans[name]
+	if !found {
+		names := make([]string, 0, len(c.spans))
+		for name := range c.spans {
+			names = append(names, name)
+		}
+		t.Fatalf("span %q isn't exported, got %v", name, names)
+	}
+	return span
+}
+
+func attribute(span *tracepb.Span, key string) *commonpb.AnyValue {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return &commonpb.AnyValue{}
+}
+
+func TestTracing(t *testing.T) {
+	ctx := gocontext.Background()
+	collector := newCollector(t)
+
+	var downstreamHeader http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstreamHeader = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	provider, err := NewProvider(ctx, "test",
+		otlptracehttp.WithInsecure(),
+		otlptracehttp.WithEndpoint(strings.TrimPrefix(collector.URL, "http://")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := New(provider, WithOpentracingBridge(), WithGlobalProvider(false))
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	app := iris.New()
+	app.UseGlobal(tr.Handler)
+	app.Get("/users/{id}", func(ctx iris.Context) {
+		span, _ := opentracing.StartSpanFromContext(ctx.Request().Context(), "opentracing child")
+		span.Finish()
+
+		req, _ := http.NewRequestWithContext(GetContextFrom(ctx), http.MethodGet, downstream.URL, nil)
+		resp, err := (&http.Client{Transport: tr.Transport(nil)}).Do(req)
+		if err != nil {
+			t.Errorf("request to downstream failed: %s", err)
+			return
+		}
+		resp.Body.Close()
+
+		ctx.StatusCode(http.StatusInternalServerError)
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-"+incomingTraceId+"-"+incomingSpanId+"-01")
+	req.Header.Set("baggage", "k=v")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	if err := tr.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	server := collector.span(t, "HTTP request (GET: /users/{id})")
+	client := collector.span(t, "HTTP GET")
+	bridged := collector.span(t, "opentracing child")
+
+	t.Run("server span", func(t *testing.T) {
+		if server.Kind != tracepb.Span_SPAN_KIND_SERVER {
+			t.Errorf("expected server kind, got %s", server.Kind)
+		}
+		if traceId := hex.EncodeToString(server.TraceId); traceId != incomingTraceId {
+			t.Errorf("expected trace id of traceparent, got %s", traceId)
+		}
+		if parentId := hex.EncodeToString(server.ParentSpanId); parentId != incomingSpanId {
+			t.Errorf("expected parent span of traceparent, got %s", parentId)
+		}
+		if route := attribute(server, "http.route").GetStringValue(); route != "/users/{id}" {
+			t.Errorf("expected http.route attribute, got %q", route)
+		}
+		if status := attribute(server, "http.status_code").GetIntValue(); status != http.StatusInternalServerError {
+			t.Errorf("expected http.status_code 500, got %d", status)
+		}
+		if server.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+			t.Errorf("expected error status, got %s", server.Status.Code)
+		}
+	})
+
+	t.Run("client span", func(t *testing.T) {
+		if client.Kind != tracepb.Span_SPAN_KIND_CLIENT {
+			t.Errorf("expected client kind, got %s", client.Kind)
+		}
+		if string(client.TraceId) != string(server.TraceId) || string(client.ParentSpanId) != string(server.SpanId) {
+			t.Errorf("expected client span to be child of server span")
+		}
+	})
+
+	t.Run("propagation", func(t *testing.T) {
+		expected := "00-" + incomingTraceId + "-" + hex.EncodeToString(client.SpanId) + "-01"
+		if traceparent := downstreamHeader.Get("traceparent"); traceparent != expected {
+			t.Errorf("expected traceparent %q, got %q", expected, traceparent)
+		}
+		if baggage := downstreamHeader.Get("baggage"); baggage != "k=v" {
+			t.Errorf("expected baggage to be propagated, got %q", baggage)
+		}
+	})
+
+	t.Run("opentracing bridge", func(t *testing.T) {
+		if string(bridged.TraceId) != string(server.TraceId) || string(bridged.ParentSpanId) != string(server.SpanId) {
+			t.Errorf("expected opentracing span to be child of server span")
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package opentracing
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Matches value which is equal to given one.
+// Numbers of different go types are compared by value (for example uint16(500) equals 500.0).
+type EqualMatcher struct {
+	value interface{}
+}
+
+func NewEqualMatcher(value interface{}) *EqualMatcher {
+	return &EqualMatcher{
+		value: value,
+	}
+}
+
+func (m *EqualMatcher) Check(value interface{}) bool {
+	return equalValues(m.value, value)
+}
+
+// Matches string with given prefix.
+type PrefixMatcher struct {
+	prefix string
+}
+
+func NewPrefixMatcher(prefix string) *PrefixMatcher {
+	return &PrefixMatcher{
+		prefix: prefix,
+	}
+}
+
+func (m *PrefixMatcher) Check(value interface{}) bool {
+	if sval, ok := value.(string); ok {
+		return strings.HasPrefix(sval, m.prefix)
+	}
+	return false
+}
+
+// Matches string with given suffix.
+type SuffixMatcher struct {
+	suffix string
+}
+
+func NewSuffixMatcher(suffix string) *SuffixMatcher {
+	return &SuffixMatcher{
+		suffix: suffix,
+	}
+}
+
+func (m *SuffixMatcher) Check(value interface{}) bool {
+	if sval, ok := value.(string); ok {
+		return strings.HasSuffix(sval, m.suffix)
+	}
+	return false
+}
+
+// Matches whole string by glob pattern, where "*" is any number of any characters
+// (including "/") and "?" is any single character.
+// For example "/api/*/users" matches "/api/v1/users".
+type GlobMatcher struct {
+	r *regexp.Regexp
+}
+
+func NewGlobMatcher(pattern string) *GlobMatcher {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.Replace(re, `\*`, `.*`, -1)
+	re = strings.Replace(re, `\?`, `.`, -1)
+
+	return &GlobMatcher{
+		r: regexp.MustCompile(`^(?s:` + re + `)$`),
+	}
+}
+
+func (m *GlobMatcher) Check(value interface{}) bool {
+	if sval, ok := value.(string); ok {
+		return m.r.MatchString(sval)
+	}
+	return false
+}
+
+// Matches number within range, bounds are inclusive.
+// Use math.Inf for open range, for example NewRangeMatcher(500, math.Inf(1)) is "value >= 500".
+type RangeMatcher struct {
+	min float64
+	max float64
+}
+
+func NewRangeMatcher(min float64, max float64) *RangeMatcher {
+	return &RangeMatcher{
+		min: min,
+		max: max,
+	}
+}
+
+// Matches numbers which are greater or equal to min.
+func NewMinMatcher(min float64) *RangeMatcher {
+	return NewRangeMatcher(min, math.Inf(1))
+}
+
+// Matches numbers which are less or equal to max.
+func NewMaxMatcher(max float64) *RangeMatcher {
+	return NewRangeMatcher(math.Inf(-1), max)
+}
+
+func (m *RangeMatcher) Check(value interface{}) bool {
+	if fval, ok := toFloat64(value); ok {
+		return m.min <= fval && fval <= m.max
+	}
+	return false
+}
+
+// Matches boolean value.
+type BoolMatcher struct {
+	value bool
+}
+
+func NewBoolMatcher(value bool) *BoolMatcher {
+	return &BoolMatcher{
+		value: value,
+	}
+}
+
+func (m *BoolMatcher) Check(value interface{}) bool {
+	if bval, ok := value.(bool); ok {
+		return bval == m.value
+	}
+	return false
+}
+
+// Matches value which is equal to one of given values (see EqualMatcher).
+type SetMatcher struct {
+	values []interface{}
+}
+
+func NewSetMatcher(values ...interface{}) *SetMatcher {
+	return &SetMatcher{
+		values: values,
+	}
+}
+
+func (m *SetMatcher) Check(value interface{}) bool {
+	for _, v := range m.values {
+		if equalValues(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches value which is matched by all matchers.
+type AndMatcher struct {
+	matchers []Matcher
+}
+
+func NewAndMatcher(matchers ...Matcher) *AndMatcher {
+	return &AndMatcher{
+		matchers: matchers,
+	}
+}
+
+func (m *AndMatcher) Check(value interface{}) bool {
+	for _, matcher := range m.matchers {
+		if !matcher.Check(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches value which is matched by any of matchers.
+type OrMatcher struct {
+	matchers []Matcher
+}
+
+func NewOrMatcher(matchers ...Matcher) *OrMatcher {
+	return &OrMatcher{
+		matchers: matchers,
+	}
+}
+
+func (m *OrMatcher) Check(value interface{}) bool {
+	for _, matcher := range m.matchers {
+		if matcher.Check(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches value which isn't matched by matcher.
+type NotMatcher struct {
+	matcher Matcher
+}
+
+func NewNotMatcher(matcher Matcher) *NotMatcher {
+	return &NotMatcher{
+		matcher: matcher,
+	}
+}
+
+func (m *NotMatcher) Check(value interface{}) bool {
+	return !m.matcher.Check(value)
+}
+
+// Returns description of matcher which depends on its content only (used for default ids of rules).
+// Custom matchers can implement fmt.Stringer to control their description.
+func describeMatcher(matcher Matcher) string {
+	switch m := matcher.(type) {
+	case fmt.Stringer:
+		return m.String()
+	case *StringRegexpMatcher:
+		return fmt.Sprintf("regexp(%s)", m.r)
+	case *PrefixMatcher:
+		return fmt.Sprintf("prefix(%q)", m.prefix)
+	case *SuffixMatcher:
+		return fmt.Sprintf("suffix(%q)", m.suffix)
+	case *GlobMatcher:
+		return fmt.Sprintf("glob(%s)", m.r)
+	case *EqualMatcher:
+		return fmt.Sprintf("equal(%s)", describeValues(m.value))
+	case *RangeMatcher:
+		return fmt.Sprintf("range(%v, %v)", m.min, m.max)
+	case *BoolMatcher:
+		return fmt.Sprintf("bool(%v)", m.value)
+	case *SetMatcher:
+		return fmt.Sprintf("in(%s)", describeValues(m.values...))
+	case *AndMatcher:
+		return fmt.Sprintf("and(%s)", describeMatchers(m.matchers))
+	case *OrMatcher:
+		return fmt.Sprintf("or(%s)", describeMatchers(m.matchers))
+	case *NotMatcher:
+		return fmt.Sprintf("not(%s)", describeMatcher(m.matcher))
+	case *MatchAllMatcher:
+		return "all"
+	default:
+		return fmt.Sprintf("%T%+v", m, m)
+	}
+}
+
+func describeMatchers(matchers []Matcher) string {
+	descriptions := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		descriptions = append(descriptions, describeMatcher(m))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+func describeValues(values ...interface{}) string {
+	descriptions := make([]string, 0, len(values))
+	for _, v := range values {
+		descriptions = append(descriptions, fmt.Sprintf("%T(%v)", v, v))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// Compares values, numbers are compared by value regardless of their go types.
+func equalValues(a interface{}, b interface{}) bool {
+	if ai, ok := toInt64(a); ok {
+		if bi, ok := toInt64(b); ok {
+			return ai == bi
+		}
+	}
+
+	if au, ok := a.(uint64); ok {
+		if bu, ok := b.(uint64); ok {
+			return au == bu
+		}
+	}
+
+	if af, ok := toFloat64(a); ok {
+		bf, ok := toFloat64(b)
+		return ok && af == bf
+	}
+
+	// NOTE: comparison of uncomparable values (slices, maps) panics
+	if a == nil || b == nil || !reflect.TypeOf(a).Comparable() {
+		return a == nil && b == nil
+	}
+
+	return a == b
+}
+
+// Returns integer value of signed and unsigned go integers (false for uint64 above math.MaxInt64).
+// NOTE: jaeger passes tag values as is, so status code can be int, uint16 or anything else
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case uintptr:
+		return int64(v), uint64(v) <= math.MaxInt64
+	case float32:
+		return int64(v), float32(int64(v)) == v
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	default:
+		return 0, false
+	}
+}
+
+// Returns value of any go number as float64.
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uintptr:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,44 @@
+package opentracing
+
+import (
+	"sync/atomic"
+
+	"github.com/kataras/iris/context"
+)
+
+// State of TagSampler rule.
+type RuleStats struct {
+	ID       string `json:"id"`
+	Tag      string `json:"tag"`
+	Matcher  string `json:"matcher"`
+	Decision string `json:"decision"`
+	Hits     uint64 `json:"hits"` // number of matched tags since start (kept between reloads)
+}
+
+// Returns rules in order of evaluation with their hit counts.
+func (t *TagSampler) Stats() []RuleStats {
+	set := t.rules.Load().(*ruleSet)
+
+	stats := make([]RuleStats, 0, len(set.all))
+	for _, rule := range set.all {
+		stats = append(stats, RuleStats{
+			ID:       rule.id,
+			Tag:      rule.Tag,
+			Matcher:  describeMatcher(rule.Matcher),
+			Decision: rule.Decision.String(),
+			Hits:     atomic.LoadUint64(rule.hits),
+		})
+	}
+
+	return stats
+}
+
+// Handler which lists rules with their hit counts as JSON.
+// Usage:
+//
+//	app.Get("/debug/sampling", sampler.DebugHandler)
+func (t *TagSampler) DebugHandler(ctx context.Context) {
+	ctx.JSON(map[string]interface{}{
+		"rules": t.Stats(),
+	})
+}
@@ -0,0 +1,187 @@
+package opentracing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTagMatches(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		data   string
+		rules  []string // "<name> <tag> <decision>" of parsed rules
+		err    string
+	}{
+		{
+			name:   "yaml",
+			format: "yaml",
+			data: `
+rules:
+  - name: skip-health
+    tag: path
+    matcher: regexp
+    pattern: ^/health
+    decision: drop
+  - name: errors
+    tag: http.status_code
+    matcher: range
+    min: 500
+    decision: take
+  - tag: http.method
+    matcher: not
+    matchers:
+      - matcher: in
+        values: [GET, HEAD]
+    decision: next
+`,
+			rules: []string{"skip-health path drop", "errors http.status_code take", " http.method next"},
+		},
+		{
+			name:   "json",
+			format: "json",
+			data:   `{"rules": [{"name": "debug", "tag": "debug", "matcher": "bool", "value": true, "decision": "take"}]}`,
+			rules:  []string{"debug debug take"},
+		},
+		{
+			name:   "bad regexp",
+			format: "yaml",
+			data:   "rules: [{tag: path, matcher: regexp, pattern: '(', decision: drop}]",
+			err:    `invalid sampling rule #1: invalid pattern "("`,
+		},
+		{
+			name:   "unknown matcher",
+			format: "yaml",
+			data:   "rules: [{tag: path, matcher: fuzzy, decision: drop}]",
+			err:    `invalid sampling rule #1: unknown matcher "fuzzy"`,
+		},
+		{
+			name:   "min greater than max",
+			format: "json",
+			data:   `{"rules": [{"tag": "http.status_code", "matcher": "range", "min": 599, "max": 500, "decision": "take"}]}`,
+			err:    "invalid sampling rule #1: min 599 is greater than max 500",
+		},
+		{
+			name:   "duplicate name",
+			format: "yaml",
+			data: `
+rules:
+  - {name: errors, tag: error, matcher: bool, value: true, decision: take}
+  - {name: errors, tag: http.status_code, matcher: range, min: 500, decision: take}
+`,
+			err: `invalid sampling rule #2: duplicate name "errors"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			matches, err := ParseTagMatches([]byte(c.data), c.format)
+
+			if c.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+					t.Fatalf("expected error %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(matches) != len(c.rules) {
+				t.Fatalf("expected %d rules, got %d", len(c.rules), len(matches))
+			}
+			for i, match := range matches {
+				rule := match.Name + " " + match.Tag + " " + match.Decision.String()
+				if rule != c.rules[i] {
+					t.Fatalf("expected rule #%d to be %q, got %q", i+1, c.rules[i], rule)
+				}
+			}
+		})
+	}
+}
+
+// Buffer of log output which can be read while sampler writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTagSamplerReloadsRulesFromFile(t *testing.T) {
+	var logs syncBuffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	dir, err := ioutil.TempDir("", "sampling-rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rules.yaml")
+	write := func(data string) {
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(sampler *TagSampler) string {
+		var ids []string
+		for _, rule := range sampler.Stats() {
+			ids = append(ids, rule.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	waitFor := func(what string, done func() bool) {
+		deadline := time.Now().Add(5 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timeout waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	write("rules: [{name: health, tag: path, matcher: prefix, pattern: /health, decision: drop}]")
+
+	sampler, err := NewTagSamplerFromFile(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sampler.Close()
+
+	if actual := ids(sampler); actual != "health" {
+		t.Fatalf("expected rules from file, got %q", actual)
+	}
+
+	write(`
+rules:
+  - {name: errors, tag: error, matcher: bool, value: true, decision: take}
+  - {name: health, tag: path, matcher: prefix, pattern: /health, decision: drop}
+`)
+	waitFor("reload of changed rules", func() bool { return ids(sampler) == "errors,health" })
+
+	write("rules: [{name: broken, tag: path, matcher: fuzzy, decision: drop}]")
+	waitFor("error of invalid rules", func() bool { return strings.Contains(logs.String(), "Can't reload sampling rules") })
+
+	if actual := ids(sampler); actual != "errors,health" {
+		t.Fatalf("expected invalid file to keep current rules, got %q", actual)
+	}
+}
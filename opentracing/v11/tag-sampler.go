@@ -1,16 +1,39 @@
 package opentracing
 
 import (
+	"fmt"
+	"hash/fnv"
+	"log"
 	"regexp"
+	"sync"
+	"sync/atomic"
 
 	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-lib/metrics"
 )
 
 type TagSampler struct {
-	tagMatches map[string][]TagMatch
+	rules atomic.Value // *ruleSet, swapped by SetMatches
+
+	metrics metrics.Factory
+	hits    sync.Map // rule id => *uint64, kept between reloads of rules
+
+	stop     chan struct{} // stops watching of rules file (see NewTagSamplerFromFile)
+	stopOnce sync.Once
+}
+
+type TagSamplerOption func(t *TagSampler)
+
+// Sets factory of "tag_sampler_decisions" counters (with "rule" and "decision" tags).
+// For example jaeger-lib/metrics/prometheus.New() or jaeger-lib/metrics/expvar.NewFactory(10).
+func WithSamplerMetrics(factory metrics.Factory) TagSamplerOption {
+	return func(t *TagSampler) {
+		t.metrics = factory
+	}
 }
 
 type TagMatch struct {
+	Name     string // id of rule which is set as "sampler.rule" tag (see ruleId for default)
 	Tag      string
 	Matcher  Matcher
 	Decision SamplingDecision
@@ -24,6 +47,31 @@ const (
 	DecisionDrop
 )
 
+func (d SamplingDecision) String() string {
+	switch d {
+	case DecisionTake:
+		return "take"
+	case DecisionDrop:
+		return "drop"
+	default:
+		return "next"
+	}
+}
+
+type ruleSet struct {
+	byTag map[string][]*tagRule
+	all   []*tagRule // in order of matches
+}
+
+// Rule with precomputed decision and counters of hits.
+type tagRule struct {
+	TagMatch
+	id       string
+	decision jaeger.SamplingDecision
+	hits     *uint64
+	counter  metrics.Counter
+}
+
 type Matcher interface {
 	Check(value interface{}) bool
 }
@@ -38,6 +86,18 @@ func NewStringRegexpMatcher(re string) *StringRegexpMatcher {
 	}
 }
 
+// Same as NewStringRegexpMatcher but returns error instead of panic for invalid regexp.
+func CompileStringRegexpMatcher(re string) (*StringRegexpMatcher, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StringRegexpMatcher{
+		r: r,
+	}, nil
+}
+
 func (m *StringRegexpMatcher) Check(value interface{}) bool {
 	if sval, ok := value.(string); ok {
 		return m.r.MatchString(sval)
@@ -51,31 +111,119 @@ func (m *MatchAllMatcher) Check(value interface{}) bool {
 	return true
 }
 
-var (
-	undecidedDecision = jaeger.SamplingDecision{Sample: false, Retryable: true, Tags: nil}
-	notSampleDecision = jaeger.SamplingDecision{
-		Sample:    false,
-		Retryable: false,
-		Tags: []jaeger.Tag{
-			jaeger.NewTag("sampler.type", "TagSampler"),
-		},
+var undecidedDecision = jaeger.SamplingDecision{Sample: false, Retryable: true, Tags: nil}
+
+// Creates sampler with given rules.
+// Rules with duplicate names don't make it fail (unlike NewTagSamplerE), error is logged
+// and ids of later duplicates are built from their content (see ruleId).
+func NewTagSampler(matches []TagMatch, opts ...TagSamplerOption) *TagSampler {
+	t := newTagSampler(opts)
+
+	if err := t.SetMatches(matches); err != nil {
+		log.Printf("Sampling rules have duplicate names, ids of later duplicates are built from their content: %s", err)
+		t.SetMatches(withoutDuplicateNames(matches))
 	}
-	sampleDecision = jaeger.SamplingDecision{
-		Sample:    true,
-		Retryable: false,
-		Tags: []jaeger.Tag{
-			jaeger.NewTag("sampler.type", "TagSampler"),
-		},
+	return t
+}
+
+// Creates sampler with given rules, returns error for duplicate names.
+func NewTagSamplerE(matches []TagMatch, opts ...TagSamplerOption) (*TagSampler, error) {
+	t := newTagSampler(opts)
+
+	if err := t.SetMatches(matches); err != nil {
+		return nil, err
 	}
-)
+	return t, nil
+}
 
-func NewTagSampler(matches []TagMatch) *TagSampler {
-	tm := make(map[string][]TagMatch)
+func newTagSampler(opts []TagSamplerOption) *TagSampler {
+	t := &TagSampler{
+		metrics: metrics.NullFactory,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Returns copy of rules where names which were already used by previous rules are cleared.
+func withoutDuplicateNames(matches []TagMatch) []TagMatch {
+	unique := make([]TagMatch, 0, len(matches))
+	names := make(map[string]bool)
 	for _, match := range matches {
-		tm[match.Tag] = append(tm[match.Tag], match)
+		if names[match.Name] {
+			match.Name = ""
+		}
+		if match.Name != "" {
+			names[match.Name] = true
+		}
+		unique = append(unique, match)
+	}
+	return unique
+}
+
+// Replaces rules of sampler, it is safe to call it concurrently with sampling.
+// Hit counts of rules with the same id are kept. Returns error for duplicate names (rules stay the same).
+func (t *TagSampler) SetMatches(matches []TagMatch) error {
+	set := &ruleSet{
+		byTag: make(map[string][]*tagRule),
+	}
+
+	names := make(map[string]bool)
+	for i, match := range matches {
+		if match.Name != "" {
+			if names[match.Name] {
+				return fmt.Errorf("invalid sampling rule #%d: duplicate name %q", i+1, match.Name)
+			}
+			names[match.Name] = true
+		}
+
+		rule := t.newRule(match)
+		set.byTag[match.Tag] = append(set.byTag[match.Tag], rule)
+		set.all = append(set.all, rule)
+	}
+
+	t.rules.Store(set)
+	return nil
+}
+
+// Returns name of rule or id built from its content ("<tag>#<hash of matcher and decision>"),
+// so id of unnamed rule doesn't change when other rules are added or removed.
+// NOTE: equal unnamed rules of the same tag share id, only the first of them is ever matched anyway
+func ruleId(match TagMatch) string {
+	if match.Name != "" {
+		return match.Name
 	}
-	return &TagSampler{
-		tagMatches: tm,
+
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "%s\n%s\n%s", match.Tag, describeMatcher(match.Matcher), match.Decision)
+	return fmt.Sprintf("%s#%08x", match.Tag, hash.Sum32())
+}
+
+func (t *TagSampler) newRule(match TagMatch) *tagRule {
+	id := ruleId(match)
+
+	hits, _ := t.hits.LoadOrStore(id, new(uint64))
+
+	decision := jaegerDecision("TagSampler", match.Decision)
+	if match.Decision != DecisionNextSampler {
+		decision.Tags = append(decision.Tags,
+			jaeger.NewTag("sampler.rule", id),
+			jaeger.NewTag("sampler.decision", match.Decision.String()),
+		)
+	}
+
+	return &tagRule{
+		TagMatch: match,
+		id:       id,
+		decision: decision,
+		hits:     hits.(*uint64),
+		counter: t.metrics.Counter(metrics.Options{
+			Name: "tag_sampler_decisions",
+			Tags: map[string]string{"rule": id, "decision": match.Decision.String()},
+		}),
 	}
 }
 
@@ -88,10 +236,14 @@ func (t *TagSampler) OnSetOperationName(span *jaeger.Span, operationName string)
 }
 
 func (t *TagSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
-	if matches, found := t.tagMatches[key]; found {
-		for _, match := range matches {
-			if match.Matcher.Check(value) {
-				return t.jaegerDecision(match.Decision)
+	set := t.rules.Load().(*ruleSet)
+
+	if rules, found := set.byTag[key]; found {
+		for _, rule := range rules {
+			if rule.Matcher.Check(value) {
+				atomic.AddUint64(rule.hits, 1)
+				rule.counter.Inc(1)
+				return rule.decision
 			}
 		}
 	}
@@ -103,18 +255,7 @@ func (s *TagSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
 }
 
 func (s *TagSampler) Close() {
-	return
-}
-
-func (t *TagSampler) jaegerDecision(decision SamplingDecision) jaeger.SamplingDecision {
-	switch decision {
-	case DecisionDrop:
-		return notSampleDecision
-	case DecisionTake:
-		return sampleDecision
-	case DecisionNextSampler:
-		return undecidedDecision
-	default:
-		return undecidedDecision
+	if s.stop != nil {
+		s.stopOnce.Do(func() { close(s.stop) })
 	}
 }
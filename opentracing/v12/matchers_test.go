@@ -0,0 +1,84 @@
+package opentracing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqualValues(t *testing.T) {
+	cases := []struct {
+		a, b  interface{}
+		equal bool
+	}{
+		{500, uint16(500), true},
+		{500, 500.0, true},
+		{int8(-1), int64(-1), true},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64), true},
+		{uint64(math.MaxUint64), int64(-1), false},
+		{0.5, float32(0.5), true},
+		{500, 500.5, false},
+		{500, "500", false},
+		{"GET", "GET", true},
+		{"GET", "POST", false},
+		{true, true, true},
+		{true, 1, false},
+		{nil, nil, true},
+		{nil, "", false},
+		{[]string{"a"}, []string{"a"}, false},
+		{map[string]int{}, "a", false},
+	}
+
+	for _, c := range cases {
+		if equal := equalValues(c.a, c.b); equal != c.equal {
+			t.Errorf("equalValues(%#v, %#v) = %v, expected %v", c.a, c.b, equal, c.equal)
+		}
+	}
+}
+
+func TestToInt64(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		i     int64
+		ok    bool
+	}{
+		{int(-5), -5, true},
+		{int8(-5), -5, true},
+		{int16(500), 500, true},
+		{int32(500), 500, true},
+		{int64(math.MinInt64), math.MinInt64, true},
+		{uint(500), 500, true},
+		{uint8(200), 200, true},
+		{uint16(500), 500, true},
+		{uint32(math.MaxUint32), math.MaxUint32, true},
+		{uint64(math.MaxInt64), math.MaxInt64, true},
+		{uint64(math.MaxInt64 + 1), 0, false},
+		{uintptr(500), 500, true},
+		{float32(500), 500, true},
+		{500.0, 500, true},
+		{500.5, 0, false},
+		{"500", 0, false},
+		{nil, 0, false},
+	}
+
+	for _, c := range cases {
+		i, ok := toInt64(c.value)
+		if ok != c.ok || (ok && i != c.i) {
+			t.Errorf("toInt64(%#v) = %d, %v; expected %d, %v", c.value, i, ok, c.i, c.ok)
+		}
+	}
+}
+
+func TestRangeMatcher(t *testing.T) {
+	m := NewMinMatcher(500)
+
+	for value, match := range map[interface{}]bool{
+		uint16(500): true,
+		599:         true,
+		499.9:       false,
+		"500":       false,
+	} {
+		if m.Check(value) != match {
+			t.Errorf("Check(%#v) = %v, expected %v", value, !match, match)
+		}
+	}
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"regexp"
 	"runtime/debug"
+	"sync"
+	"sync/atomic"
 
 	gocontext "context"
 
@@ -16,23 +17,169 @@ import (
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 )
 
-var (
+// Key of trace context in iris context values (read it by GetContextFrom/LookupContextFrom).
+// NOTE: iris values are keyed by plain strings, so key is namespaced by package name.
+const traceCtxKey = "opentracing.trace-ctx"
+
+// Tracing middleware which owns its tracer.
+// Usage:
+//
+//	tracer, closer, err := opentracing.TracerFromEnv()
+//	...
+//	t := opentracing.New(tracer, opentracing.WithCloser(closer))
+//	defer t.Close()
+//	app.UseGlobal(t.Handler)
+type Tracing struct {
+	once   sync.Once
 	tracer opentracing.Tracer
 	closer io.Closer
+	global bool
+
+	spanName    SpanNamer
+	normalizers []PathNormalizer
+	errorStatus func(status int) bool
+
+	malformedPolicy MalformedPolicy
+	malformedHook   func(ctx context.Context, err error)
+	malformed       uint64 // number of requests with malformed trace headers (atomic)
+}
+
+// Defines what middleware does when incoming trace headers are malformed.
+type MalformedPolicy int
 
-	reNum  *regexp.Regexp
-	reUuid *regexp.Regexp
-	reHash *regexp.Regexp
+const (
+	MalformedNewRoot MalformedPolicy = iota // start new root span and mark it with warning (default)
+	MalformedReject                         // respond 400 Bad Request and stop execution
+	MalformedIgnore                         // start new root span as if there were no trace headers
 )
 
-// func StartRootSpan(spanName string) (opentracing.Span, gocontext.Context) {
-// 	span = tracer.StartSpan(spanName, opentracing.ChildOf(spanCtx))
-// 	traceCtx := opentracing.ContextWithSpan(gocontext.Background(), span)
-// }
+type Option func(t *Tracing)
+
+// Sets closer which is called by Tracing.Close (for example closer of jaeger tracer).
+func WithCloser(closer io.Closer) Option {
+	return func(t *Tracing) {
+		t.closer = closer
+	}
+}
+
+// Sets whether tracer should be installed as opentracing global tracer (default is true).
+// Global tracer is used by opentracing.StartSpanFromContext and client package.
+func WithGlobalTracer(global bool) Option {
+	return func(t *Tracing) {
+		t.global = global
+	}
+}
+
+// Sets function which returns span name for request (default is DefaultSpanName).
+func WithSpanNamer(namer SpanNamer) Option {
+	return func(t *Tracing) {
+		t.spanName = namer
+	}
+}
+
+// Sets normalizers of request path which are used for span name
+// when request doesn't match any route (default is DefaultNormalizers).
+func WithPathNormalizers(normalizers ...PathNormalizer) Option {
+	return func(t *Tracing) {
+		t.normalizers = normalizers
+	}
+}
+
+// Sets function which decides whether response status marks span as error (default is DefaultErrorStatus).
+func WithErrorStatus(isError func(status int) bool) Option {
+	return func(t *Tracing) {
+		t.errorStatus = isError
+	}
+}
+
+// Sets policy for requests with malformed trace headers (default is MalformedNewRoot).
+func WithMalformedPolicy(policy MalformedPolicy) Option {
+	return func(t *Tracing) {
+		t.malformedPolicy = policy
+	}
+}
+
+// Sets function which is called for each request with malformed trace headers
+// (default logs error by standard log package).
+func WithMalformedHook(hook func(ctx context.Context, err error)) Option {
+	return func(t *Tracing) {
+		t.malformedHook = hook
+	}
+}
+
+// Creates tracing middleware.
+// Nil tracer means lazy setup from jaeger env vars on the first request (see TracerFromEnv).
+func New(tracer opentracing.Tracer, opts ...Option) *Tracing {
+	t := &Tracing{
+		tracer:      tracer,
+		global:      true,
+		normalizers: DefaultNormalizers,
+		errorStatus: DefaultErrorStatus,
+
+		malformedHook: logMalformed,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.spanName == nil {
+		t.spanName = t.DefaultSpanName
+	}
+
+	if tracer != nil {
+		t.once.Do(t.installGlobal)
+	}
+
+	return t
+}
+
+// Returns tracer (sets it up from env vars if it wasn't set yet).
+func (t *Tracing) Tracer() opentracing.Tracer {
+	t.once.Do(t.setupFromEnv)
+	return t.tracer
+}
+
+// Flushes and closes tracer. Call it on shutdown.
+func (t *Tracing) Close() error {
+	if t.closer == nil {
+		return nil
+	}
+	return t.closer.Close()
+}
+
+func (t *Tracing) setupFromEnv() {
+	tracer, closer, err := TracerFromEnv()
+	if err != nil {
+		log.Printf("Tracing is disabled: %s", err)
+		tracer, closer = opentracing.NoopTracer{}, nil
+	}
+
+	t.tracer = tracer
+	t.closer = closer
+	t.installGlobal()
+}
+
+func (t *Tracing) installGlobal() {
+	if t.global {
+		opentracing.SetGlobalTracer(t.tracer)
+	}
+}
+
+// Returns trace context from iris context and false if Middleware wasn't installed.
+func LookupContextFrom(ctx context.Context) (gocontext.Context, bool) {
+	traceCtx, ok := ctx.Values().Get(traceCtxKey).(gocontext.Context)
+	return traceCtx, ok
+}
 
+// Returns trace context from iris context.
+// Falls back to context of the request when Middleware wasn't installed
+// (it carries span of opentelemetry middleware with opentracing bridge for example).
 func GetContextFrom(ctx context.Context) gocontext.Context {
-	// TODO: fix crash when Middleware wasn't added to app.UseGlobal(...)
-	return ctx.Values().Get("opentrace-ctx").(gocontext.Context)
+	if traceCtx, ok := LookupContextFrom(ctx); ok {
+		return traceCtx
+	}
+	return ctx.Request().Context()
 }
 
 func StartSpanFromContext(ctx context.Context, spanName string) (opentracing.Span, gocontext.Context) {
@@ -40,22 +187,49 @@ func StartSpanFromContext(ctx context.Context, spanName string) (opentracing.Spa
 	return opentracing.StartSpanFromContext(tctx, spanName)
 }
 
-func Middleware(ctx context.Context) {
+// Returns span name with route template (for example "HTTP request (GET: /users/{id:uint64})").
+// Falls back to path normalized by configured normalizers when request doesn't match any route.
+func (t *Tracing) DefaultSpanName(ctx context.Context) string {
+	var path string
+
+	if route := currentRoute(ctx); route != nil {
+		path = route.Path()
+	} else {
+		path = ctx.Path()
+		for _, normalize := range t.normalizers {
+			path = normalize(path)
+		}
+	}
+
+	return fmt.Sprintf("HTTP request (%s: %s)", ctx.Method(), path)
+}
+
+// Middleware handler which starts span for each request.
+func (t *Tracing) Handler(ctx context.Context) {
+	tracer := t.Tracer()
+
 	carrier := opentracing.HTTPHeadersCarrier(ctx.Request().Header)
 	spanCtx, err := tracer.Extract(opentracing.HTTPHeaders, carrier)
 
 	var span opentracing.Span
 
-	path := reNum.ReplaceAllString(ctx.Path(), "{num}")
-	path = reUuid.ReplaceAllString(path, "{uuid}")
-	path = reHash.ReplaceAllString(path, "{hash}")
+	spanName := t.spanName(ctx)
 
-	spanName := fmt.Sprintf("HTTP request (%s: %s)", ctx.Method(), path)
+	switch {
+	case err == nil:
+		span = tracer.StartSpan(spanName, opentracing.ChildOf(spanCtx))
+	case err == opentracing.ErrSpanContextNotFound:
+		span = tracer.StartSpan(spanName)
+	default:
+		atomic.AddUint64(&t.malformed, 1)
+		if t.malformedHook != nil {
+			t.malformedHook(ctx, err)
+		}
 
-	if err != nil {
 		span = tracer.StartSpan(spanName)
 
-		if err != opentracing.ErrSpanContextNotFound {
+		switch t.malformedPolicy {
+		case MalformedReject:
 			span.SetTag("error", true)
 			span.LogKV(
 				"event", "error",
@@ -63,44 +237,76 @@ func Middleware(ctx context.Context) {
 			)
 			ctx.StatusCode(iris.StatusBadRequest)
 			ctx.StopExecution()
+
+			setRequestTags(span, ctx)
+			setResponseTags(span, ctx, t.errorStatus)
+			span.Finish()
+			return
+
+		case MalformedNewRoot:
+			span.SetTag("warning", "malformed trace header")
+			span.LogKV(
+				"event", "warning",
+				"error", err.Error(),
+			)
 		}
-	} else {
-		span = tracer.StartSpan(spanName, opentracing.ChildOf(spanCtx))
 	}
 
-	traceCtx := opentracing.ContextWithSpan(gocontext.Background(), span)
+	// NOTE: request's context carries values of previous middlewares (request-id for example)
+	traceCtx := opentracing.ContextWithSpan(ctx.Request().Context(), span)
+
+	ctx.Values().Set(traceCtxKey, traceCtx)
 
-	ctx.Values().Set("opentrace-ctx", traceCtx)
+	// NOTE: other middlewares take span by opentracing.SpanFromContext(ctx.Request().Context())
+	ctx.ResetRequest(ctx.Request().WithContext(traceCtx))
 
-	span.SetTag("path", ctx.Path()).
-		SetTag("method", ctx.Method())
+	setRequestTags(span, ctx)
 
 	defer func() {
+		// NOTE: panic isn't swallowed here, install recovery middleware after this one
+		//       to turn panics into 500 responses
 		if r := recover(); r != nil {
 			span.SetTag("panic", true).
 				SetTag("error", true).
 				SetTag("panic-message", r).
 				LogKV("trace", string(debug.Stack()))
+			span.Finish()
+
+			panic(r)
 		}
 
+		// NOTE: tags of response are set from the real status written by handlers
+		setResponseTags(span, ctx, t.errorStatus)
+
 		span.Finish()
 	}()
 
 	ctx.Next()
 }
 
-func NewTracerFromEnv() (opentracing.Tracer, io.Closer) {
+// Returns number of requests with malformed trace headers.
+func (t *Tracing) MalformedHeaders() uint64 {
+	return atomic.LoadUint64(&t.malformed)
+}
+
+func logMalformed(ctx context.Context, err error) {
+	log.Printf("Malformed trace header in request %s %s from %s: %s", ctx.Method(), ctx.Path(), ctx.RemoteAddr(), err)
+}
+
+// Creates jaeger tracer from env vars.
+// SEE: https://github.com/jaegertracing/jaeger-client-go#environment-variables
+func TracerFromEnv() (opentracing.Tracer, io.Closer, error) {
 	cfg, err := jaegercfg.FromEnv()
 	if err != nil {
-		log.Fatalf("Can't parse jaeger config from env vars: %s", err)
+		return nil, nil, fmt.Errorf("can't parse jaeger config from env vars: %s", err)
 	}
 
 	tracer, closer, err := cfg.NewTracer()
 	if err != nil {
-		log.Fatalf("Can't init jaeger tracing: %s", err)
+		return nil, nil, fmt.Errorf("can't init jaeger tracing: %s", err)
 	}
 
-	return tracer, closer
+	return tracer, closer, nil
 }
 
 func Jsonify(value interface{}) string {
@@ -112,22 +318,25 @@ func Jsonify(value interface{}) string {
 	return string(bytes)
 }
 
-// TODO: rewrite whole module:
-//	- remove init() function
-//  - create middleware object instead of Middleware function
-//  - move global tracer into middleware object
-//  - delete this function
-func SetTracer(t opentracing.Tracer, c io.Closer) {
-	tracer = t
-	closer = c
-	opentracing.SetGlobalTracer(tracer)
+// Tracing used by deprecated package level functions.
+var std = New(nil)
+
+// Deprecated: use New(tracer, opts...).Handler instead.
+func Middleware(ctx context.Context) {
+	std.Handler(ctx)
 }
 
-func init() {
-	tracer, closer = NewTracerFromEnv()
-	opentracing.SetGlobalTracer(tracer)
+// Deprecated: use TracerFromEnv instead, it returns error instead of exiting.
+func NewTracerFromEnv() (opentracing.Tracer, io.Closer) {
+	tracer, closer, err := TracerFromEnv()
+	if err != nil {
+		log.Fatalf("%s", err)
+	}
 
-	reNum = regexp.MustCompile(`\d+`)
-	reUuid = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
-	reHash = regexp.MustCompile(`[0-9a-f]{32}`)
+	return tracer, closer
+}
+
+// Deprecated: use New(tracer, WithCloser(closer)) instead.
+func SetTracer(t opentracing.Tracer, c io.Closer) {
+	std = New(t, WithCloser(c))
 }
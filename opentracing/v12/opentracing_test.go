@@ -0,0 +1,166 @@
+package opentracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/context"
+	"github.com/uber/jaeger-client-go"
+)
+
+// Returns tracing middleware which reports all spans to returned in-memory reporter.
+func newTestTracing(t *testing.T, opts ...Option) (*Tracing, *jaeger.InMemoryReporter) {
+	fallback, err := NewProbabilisticFallbackSampler(1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer, reporter := newTestTracer(t, NewChainSampler(fallback))
+	opts = append([]Option{WithGlobalTracer(false)}, opts...)
+	return New(tracer, opts...), reporter
+}
+
+func serve(t *testing.T, app *iris.Application, path string, header http.Header) *httptest.ResponseRecorder {
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlerAppliesMalformedPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  MalformedPolicy
+		status  int
+		handled bool
+		warning interface{}
+	}{
+		{"reject", MalformedReject, http.StatusBadRequest, false, nil},
+		{"new root", MalformedNewRoot, http.StatusOK, true, "malformed trace header"},
+		{"ignore", MalformedIgnore, http.StatusOK, true, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var hooked error
+			tracing, reporter := newTestTracing(t,
+				WithMalformedPolicy(c.policy),
+				WithMalformedHook(func(ctx context.Context, err error) { hooked = err }),
+			)
+
+			handled := false
+			app := iris.New()
+			app.Get("/users", tracing.Handler, func(ctx iris.Context) {
+				handled = true
+			})
+
+			w := serve(t, app, "/users", http.Header{"Uber-Trace-Id": {"not-a-trace-id"}})
+
+			if w.Code != c.status {
+				t.Fatalf("expected status %d, got %d", c.status, w.Code)
+			}
+			if handled != c.handled {
+				t.Fatalf("expected handler to be called: %v, got %v", c.handled, handled)
+			}
+			if hooked == nil {
+				t.Fatal("expected malformed hook to be called")
+			}
+			if n := tracing.MalformedHeaders(); n != 1 {
+				t.Fatalf("expected 1 malformed header to be counted, got %d", n)
+			}
+
+			spans := reporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 reported span, got %d", len(spans))
+			}
+			span := spans[0].(*jaeger.Span)
+			if warning := span.Tags()["warning"]; warning != c.warning {
+				t.Fatalf("expected warning tag %v, got %v", c.warning, warning)
+			}
+			if span.SpanContext().ParentID() != 0 {
+				t.Fatalf("expected root span, got parent %s", span.SpanContext().ParentID())
+			}
+		})
+	}
+}
+
+func TestHandlerNamesSpansByRouteTemplate(t *testing.T) {
+	tracing, reporter := newTestTracing(t)
+
+	app := iris.New()
+	app.UseGlobal(tracing.Handler)
+	app.Get("/users/{id:uint64}", func(ctx iris.Context) {})
+	// NOTE: global middlewares are called for not found requests too
+	app.OnErrorCode(iris.StatusNotFound, func(ctx iris.Context) {})
+
+	serve(t, app, "/users/42", nil)
+	serve(t, app, "/v2/users/42", nil)
+
+	spans := reporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 reported spans, got %d", len(spans))
+	}
+
+	expected := []string{
+		"HTTP request (GET: /users/{id:uint64})",
+		"HTTP request (GET: /v2/users/{num})",
+	}
+	for i, name := range expected {
+		if actual := spans[i].(*jaeger.Span).OperationName(); actual != name {
+			t.Fatalf("expected span name %q, got %q", name, actual)
+		}
+	}
+
+	if route := spans[0].(*jaeger.Span).Tags()["http.route"]; route != "/users/{id:uint64}" {
+		t.Fatalf("expected http.route tag of matched route, got %v", route)
+	}
+}
+
+func TestHandlerTagsResponseStatus(t *testing.T) {
+	tracing, reporter := newTestTracing(t)
+
+	app := iris.New()
+	app.UseGlobal(tracing.Handler)
+	app.Get("/ok", func(ctx iris.Context) {
+		ctx.WriteString("ok")
+	})
+	app.Get("/fail", func(ctx iris.Context) {
+		ctx.StatusCode(iris.StatusServiceUnavailable)
+		ctx.WriteString("try later")
+	})
+
+	serve(t, app, "/ok", nil)
+	serve(t, app, "/fail", nil)
+
+	spans := reporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 reported spans, got %d", len(spans))
+	}
+
+	cases := []struct {
+		status uint16
+		error  interface{}
+	}{
+		{http.StatusOK, nil},
+		{http.StatusServiceUnavailable, true},
+	}
+	for i, c := range cases {
+		tags := spans[i].(*jaeger.Span).Tags()
+		if status := tags["http.status_code"]; status != c.status {
+			t.Fatalf("expected http.status_code tag %d, got %v", c.status, status)
+		}
+		if err := tags["error"]; err != c.error {
+			t.Fatalf("expected error tag %v for status %d, got %v", c.error, c.status, err)
+		}
+	}
+}
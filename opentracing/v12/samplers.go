@@ -0,0 +1,300 @@
+package opentracing
+
+import (
+	"sync"
+
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+// Composable jaeger v2 samplers.
+// Each of them takes span, drops span or leaves decision to the next sampler of ChainSampler.
+// Usage:
+//
+//	fallback, err := opentracing.NewProbabilisticFallbackSampler(0.01)
+//	...
+//	sampler := opentracing.NewChainSampler(
+//		opentracing.NewOperationSampler(opentracing.OperationMatch{
+//			Matcher:  opentracing.NewStringRegexpMatcher(`/health`),
+//			Decision: opentracing.DecisionDrop,
+//		}),
+//		opentracing.NewErrorSampler(),
+//		opentracing.NewRouteRateLimitingSampler(10, nil),
+//		fallback,
+//	)
+//	tracer, closer := jaeger.NewTracer("service", sampler, reporter)
+//
+// NOTE: span which wasn't taken by anyone stays undecided until it is finished,
+//       so ErrorSampler still can take it on finish before ProbabilisticFallbackSampler.
+
+// Returns decision for sampler of given type.
+func jaegerDecision(samplerType string, decision SamplingDecision) jaeger.SamplingDecision {
+	switch decision {
+	case DecisionTake:
+		return jaeger.SamplingDecision{
+			Sample:    true,
+			Retryable: false,
+			Tags:      []jaeger.Tag{jaeger.NewTag("sampler.type", samplerType)},
+		}
+	case DecisionDrop:
+		return jaeger.SamplingDecision{
+			Sample:    false,
+			Retryable: false,
+			Tags:      []jaeger.Tag{jaeger.NewTag("sampler.type", samplerType)},
+		}
+	default:
+		return undecidedDecision
+	}
+}
+
+// Returns true if sampler made final decision.
+func isDecided(decision jaeger.SamplingDecision) bool {
+	return decision.Sample || !decision.Retryable
+}
+
+// Sampler which asks samplers in order until one of them decides.
+type ChainSampler struct {
+	jaeger.SamplerV2Base
+	samplers []jaeger.SamplerV2
+}
+
+func NewChainSampler(samplers ...jaeger.SamplerV2) *ChainSampler {
+	return &ChainSampler{
+		samplers: samplers,
+	}
+}
+
+func (c *ChainSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return c.ask(func(s jaeger.SamplerV2) jaeger.SamplingDecision {
+		return s.OnCreateSpan(span)
+	})
+}
+
+func (c *ChainSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return c.ask(func(s jaeger.SamplerV2) jaeger.SamplingDecision {
+		return s.OnSetOperationName(span, operationName)
+	})
+}
+
+func (c *ChainSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	return c.ask(func(s jaeger.SamplerV2) jaeger.SamplingDecision {
+		return s.OnSetTag(span, key, value)
+	})
+}
+
+func (c *ChainSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return c.ask(func(s jaeger.SamplerV2) jaeger.SamplingDecision {
+		return s.OnFinishSpan(span)
+	})
+}
+
+func (c *ChainSampler) Close() {
+	for _, s := range c.samplers {
+		s.Close()
+	}
+}
+
+func (c *ChainSampler) ask(call func(s jaeger.SamplerV2) jaeger.SamplingDecision) jaeger.SamplingDecision {
+	for _, s := range c.samplers {
+		if decision := call(s); isDecided(decision) {
+			return decision
+		}
+	}
+	return undecidedDecision
+}
+
+// Rule of OperationSampler.
+type OperationMatch struct {
+	Matcher  Matcher
+	Decision SamplingDecision
+}
+
+// Sampler which decides by operation name of span, first matched rule wins.
+type OperationSampler struct {
+	jaeger.SamplerV2Base
+	matches []OperationMatch
+}
+
+func NewOperationSampler(matches ...OperationMatch) *OperationSampler {
+	return &OperationSampler{
+		matches: matches,
+	}
+}
+
+func (o *OperationSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return o.decide(span.OperationName())
+}
+
+func (o *OperationSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return o.decide(operationName)
+}
+
+func (o *OperationSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (o *OperationSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (o *OperationSampler) decide(operationName string) jaeger.SamplingDecision {
+	for _, match := range o.matches {
+		if match.Matcher.Check(operationName) {
+			return jaegerDecision("OperationSampler", match.Decision)
+		}
+	}
+	return undecidedDecision
+}
+
+// Sampler which takes limited number of spans per second for each route
+// (route is taken from "http.route" tag which is set by tracing middleware).
+// Spans above limit are left to the next sampler.
+type RouteRateLimitingSampler struct {
+	jaeger.SamplerV2Base
+
+	perSecond float64
+	overrides map[string]float64
+
+	mu       sync.Mutex
+	limiters map[string]*utils.ReconfigurableRateLimiter
+}
+
+// Creates sampler with default limit for all routes and limits for specific routes
+// (for example map[string]float64{"/users/{id}": 1}). Zero limit disables sampler for route.
+func NewRouteRateLimitingSampler(perSecond float64, overrides map[string]float64) *RouteRateLimitingSampler {
+	return &RouteRateLimitingSampler{
+		perSecond: perSecond,
+		overrides: overrides,
+		limiters:  make(map[string]*utils.ReconfigurableRateLimiter),
+	}
+}
+
+func (r *RouteRateLimitingSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (r *RouteRateLimitingSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (r *RouteRateLimitingSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	if key != tagHTTPRoute {
+		return undecidedDecision
+	}
+
+	route, ok := value.(string)
+	if !ok {
+		return undecidedDecision
+	}
+
+	if limiter := r.limiter(route); limiter != nil && limiter.CheckCredit(1.0) {
+		return jaegerDecision("RouteRateLimitingSampler", DecisionTake)
+	}
+	return undecidedDecision
+}
+
+func (r *RouteRateLimitingSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (r *RouteRateLimitingSampler) limiter(route string) *utils.ReconfigurableRateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, found := r.limiters[route]
+	if !found {
+		perSecond, ok := r.overrides[route]
+		if !ok {
+			perSecond = r.perSecond
+		}
+
+		if perSecond <= 0 {
+			r.limiters[route] = nil
+			return nil
+		}
+
+		// NOTE: balance of at least one credit lets rates below 1 per second to take spans
+		maxBalance := perSecond
+		if maxBalance < 1 {
+			maxBalance = 1
+		}
+
+		limiter = utils.NewRateLimiter(perSecond, maxBalance)
+		r.limiters[route] = limiter
+	}
+
+	return limiter
+}
+
+// Sampler which takes given fraction of traces (by trace id) when span is finished,
+// so rules of previous samplers see tags of the span first. Other spans are left undecided
+// (undecided span isn't reported), so it should be the last sampler of ChainSampler.
+// Trace stays undecided until then, so requests to other services made during the span
+// carry unsampled trace context.
+type ProbabilisticFallbackSampler struct {
+	jaeger.SamplerV2Base
+	sampler *jaeger.ProbabilisticSampler
+}
+
+func NewProbabilisticFallbackSampler(rate float64) (*ProbabilisticFallbackSampler, error) {
+	sampler, err := jaeger.NewProbabilisticSampler(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProbabilisticFallbackSampler{
+		sampler: sampler,
+	}, nil
+}
+
+func (p *ProbabilisticFallbackSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (p *ProbabilisticFallbackSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (p *ProbabilisticFallbackSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+// NOTE: decision depends on trace id only, so all spans of the trace get the same one
+func (p *ProbabilisticFallbackSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	if sampled, _ := p.sampler.IsSampled(span.SpanContext().TraceID(), span.OperationName()); sampled {
+		return jaegerDecision("ProbabilisticFallbackSampler", DecisionTake)
+	}
+	return undecidedDecision
+}
+
+// Sampler which always takes spans with error=true tag.
+// Tag is checked when it is set and again when span is finished.
+type ErrorSampler struct {
+	jaeger.SamplerV2Base
+}
+
+func NewErrorSampler() *ErrorSampler {
+	return &ErrorSampler{}
+}
+
+func (e *ErrorSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (e *ErrorSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return undecidedDecision
+}
+
+func (e *ErrorSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	if key == "error" && value == true {
+		return jaegerDecision("ErrorSampler", DecisionTake)
+	}
+	return undecidedDecision
+}
+
+func (e *ErrorSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	if span.Tags()["error"] == true {
+		return jaegerDecision("ErrorSampler", DecisionTake)
+	}
+	return undecidedDecision
+}
@@ -0,0 +1,69 @@
+package opentracing
+
+import (
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+)
+
+// Returns tracer with given sampler which keeps reported spans in memory.
+func newTestTracer(t *testing.T, sampler *ChainSampler) (opentracing.Tracer, *jaeger.InMemoryReporter) {
+	reporter := jaeger.NewInMemoryReporter()
+	tracer, closer := jaeger.NewTracer("test", sampler, reporter)
+	t.Cleanup(func() { closer.Close() })
+	return tracer, reporter
+}
+
+func TestFallbackSamplerLeavesTagRulesFirst(t *testing.T) {
+	fallback, err := NewProbabilisticFallbackSampler(1.0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sampler := NewChainSampler(
+		NewTagSampler([]TagMatch{{
+			Tag:      "path",
+			Matcher:  NewStringRegexpMatcher(`^/health`),
+			Decision: DecisionDrop,
+		}}),
+		fallback,
+	)
+	tracer, reporter := newTestTracer(t, sampler)
+
+	health := tracer.StartSpan("HTTP request")
+	health.SetTag("path", "/health")
+	health.Finish()
+
+	users := tracer.StartSpan("HTTP request")
+	users.SetTag("path", "/users")
+	users.Finish()
+
+	spans := reporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected only /users span to be reported, got %d spans", len(spans))
+	}
+	if path := spans[0].(*jaeger.Span).Tags()["path"]; path != "/users" {
+		t.Fatalf("expected /users span to be reported, got %v", path)
+	}
+}
+
+func TestFallbackSamplerLeavesErrorsToErrorSampler(t *testing.T) {
+	fallback, err := NewProbabilisticFallbackSampler(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer, reporter := newTestTracer(t, NewChainSampler(NewErrorSampler(), fallback))
+
+	tracer.StartSpan("ok").Finish()
+
+	failed := tracer.StartSpan("failed")
+	failed.SetTag("error", true)
+	failed.Finish()
+
+	spans := reporter.GetSpans()
+	if len(spans) != 1 || spans[0].(*jaeger.Span).OperationName() != "failed" {
+		t.Fatalf("expected only failed span to be reported, got %d spans", len(spans))
+	}
+}
@@ -0,0 +1,82 @@
+package opentracing
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/kataras/iris/v12/context"
+)
+
+// Returns span name for request.
+type SpanNamer func(ctx context.Context) string
+
+// Replaces variable parts of request path to keep number of span names bounded.
+type PathNormalizer func(path string) string
+
+var (
+	reUuid = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	reHash = regexp.MustCompile(`\b[0-9a-fA-F]{32}\b`)
+	reNum  = regexp.MustCompile(`^\d+$`)
+
+	DefaultNormalizers = []PathNormalizer{
+		NormalizeUUIDs,
+		NormalizeHashes,
+		NormalizeNumbers,
+	}
+)
+
+// Replaces UUIDs with "{uuid}".
+func NormalizeUUIDs(path string) string {
+	return reUuid.ReplaceAllString(path, "{uuid}")
+}
+
+// Replaces md5-like hashes with "{hash}".
+func NormalizeHashes(path string) string {
+	return reHash.ReplaceAllString(path, "{hash}")
+}
+
+// Replaces numeric path segments with "{num}" ("/v2/users/42" becomes "/v2/users/{num}").
+func NormalizeNumbers(path string) string {
+	return NormalizeSegments(reNum, "{num}")(path)
+}
+
+// Returns normalizer which replaces whole path segments matching re with replacement.
+// For example: NormalizeSegments(regexp.MustCompile(`^[a-z0-9-]+-\d+$`), "{slug}")
+func NormalizeSegments(re *regexp.Regexp, replacement string) PathNormalizer {
+	return func(path string) string {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			if segment != "" && re.MatchString(segment) {
+				segments[i] = replacement
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+}
+
+// Returns normalizer which replaces all matches of re with replacement.
+func NormalizeRegexp(re *regexp.Regexp, replacement string) PathNormalizer {
+	return func(path string) string {
+		return re.ReplaceAllString(path, replacement)
+	}
+}
+
+// Returns route matched by current request or nil.
+// Iris doesn't reset route name of pooled context, so not found request can see route of previous request,
+// route is trusted only when it resolves to the request path.
+func currentRoute(ctx context.Context) context.RouteReadOnly {
+	route := ctx.GetCurrentRoute()
+	if route == nil || route.Method() != ctx.Method() {
+		return nil
+	}
+
+	args := make([]string, 0, len(ctx.Params().Store))
+	for _, param := range ctx.Params().Store {
+		args = append(args, param.String())
+	}
+	if route.ResolvePath(args...) != ctx.Path() {
+		return nil
+	}
+
+	return route
+}
@@ -0,0 +1,329 @@
+package opentracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rules of TagSampler in config file.
+// Example of YAML file:
+//
+//	rules:
+//	  - name: skip-health
+//	    tag: path
+//	    matcher: regexp
+//	    pattern: ^/health
+//	    decision: drop
+//	  - tag: http.status_code
+//	    matcher: range
+//	    min: 500
+//	    decision: take
+//	  - tag: http.method
+//	    matcher: not
+//	    matchers:
+//	      - matcher: in
+//	        values: [GET, HEAD]
+//	    decision: take
+//	  - tag: debug
+//	    matcher: all
+//	    decision: take
+type RulesConfig struct {
+	Rules []RuleConfig `yaml:"rules" json:"rules"`
+}
+
+type RuleConfig struct {
+	Name          string `yaml:"name,omitempty" json:"name,omitempty"` // id of rule (see TagMatch.Name)
+	Tag           string `yaml:"tag" json:"tag"`
+	MatcherConfig `yaml:",inline"`
+	Decision      string `yaml:"decision" json:"decision"` // "take", "drop" or "next"
+}
+
+// Matcher of rule, fields which are used depend on type of matcher:
+//   - "regexp", "prefix", "suffix", "glob": pattern
+//   - "equal", "bool": value
+//   - "in": values
+//   - "range": min and/or max (inclusive)
+//   - "and", "or": matchers
+//   - "not": single item of matchers
+//   - "all": nothing
+type MatcherConfig struct {
+	Matcher  string          `yaml:"matcher" json:"matcher"`
+	Pattern  string          `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	Value    interface{}     `yaml:"value,omitempty" json:"value,omitempty"`
+	Values   []interface{}   `yaml:"values,omitempty" json:"values,omitempty"`
+	Min      *float64        `yaml:"min,omitempty" json:"min,omitempty"`
+	Max      *float64        `yaml:"max,omitempty" json:"max,omitempty"`
+	Matchers []MatcherConfig `yaml:"matchers,omitempty" json:"matchers,omitempty"`
+}
+
+// Parses and validates rules, format is "json" or "yaml".
+func ParseTagMatches(data []byte, format string) ([]TagMatch, error) {
+	var cfg RulesConfig
+
+	var err error
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+	case "yaml", "yml":
+		err = yaml.UnmarshalStrict(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unknown format of sampling rules %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't parse sampling rules: %s", err)
+	}
+
+	names := make(map[string]bool)
+
+	matches := make([]TagMatch, 0, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		match, err := rule.tagMatch()
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling rule #%d: %s", i+1, err)
+		}
+
+		if rule.Name != "" {
+			if names[rule.Name] {
+				return nil, fmt.Errorf("invalid sampling rule #%d: duplicate name %q", i+1, rule.Name)
+			}
+			names[rule.Name] = true
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// Loads rules from file, format is chosen by extension (".json", ".yaml" or ".yml").
+func LoadTagMatches(path string) ([]TagMatch, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+
+	return ParseTagMatches(data, format)
+}
+
+// Creates sampler with rules from file (options are the same as for NewTagSampler).
+// When interval is positive file is checked for changes with given interval and rules are reloaded.
+// Invalid file doesn't replace current rules (error is logged).
+// Watching is stopped by Close.
+func NewTagSamplerFromFile(path string, interval time.Duration, opts ...TagSamplerOption) (*TagSampler, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := LoadTagMatches(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := NewTagSamplerE(matches, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		t.stop = make(chan struct{})
+		go t.watch(path, interval, info)
+	}
+
+	return t, nil
+}
+
+func (t *TagSampler) watch(path string, interval time.Duration, last os.FileInfo) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Can't check sampling rules file %s: %s", path, err)
+			continue
+		}
+
+		if info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+
+		// NOTE: broken file is reported once, next attempt is made after next change
+		last = info
+
+		matches, err := LoadTagMatches(path)
+		if err != nil {
+			log.Printf("Can't reload sampling rules from %s: %s", path, err)
+			continue
+		}
+
+		if err := t.SetMatches(matches); err != nil {
+			log.Printf("Can't reload sampling rules from %s: %s", path, err)
+		}
+	}
+}
+
+func (r RuleConfig) tagMatch() (TagMatch, error) {
+	if r.Tag == "" {
+		return TagMatch{}, fmt.Errorf("tag is required")
+	}
+
+	decision, err := parseDecision(r.Decision)
+	if err != nil {
+		return TagMatch{}, err
+	}
+
+	matcher, err := r.MatcherConfig.NewMatcher()
+	if err != nil {
+		return TagMatch{}, err
+	}
+
+	return TagMatch{
+		Name:     r.Name,
+		Tag:      r.Tag,
+		Matcher:  matcher,
+		Decision: decision,
+	}, nil
+}
+
+// Creates matcher and validates its config.
+func (m MatcherConfig) NewMatcher() (Matcher, error) {
+	switch m.Matcher {
+	case "regexp":
+		matcher, err := CompileStringRegexpMatcher(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", m.Pattern, err)
+		}
+		return matcher, nil
+
+	case "prefix", "suffix", "glob":
+		if m.Pattern == "" {
+			return nil, fmt.Errorf("pattern is required for %q matcher", m.Matcher)
+		}
+		switch m.Matcher {
+		case "prefix":
+			return NewPrefixMatcher(m.Pattern), nil
+		case "suffix":
+			return NewSuffixMatcher(m.Pattern), nil
+		default:
+			return NewGlobMatcher(m.Pattern), nil
+		}
+
+	case "equal":
+		if err := checkScalar(m.Value); err != nil {
+			return nil, err
+		}
+		return NewEqualMatcher(m.Value), nil
+
+	case "bool":
+		value, ok := m.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value of \"bool\" matcher must be true or false, got %v", m.Value)
+		}
+		return NewBoolMatcher(value), nil
+
+	case "in":
+		if len(m.Values) == 0 {
+			return nil, fmt.Errorf("values are required for \"in\" matcher")
+		}
+		for _, value := range m.Values {
+			if err := checkScalar(value); err != nil {
+				return nil, err
+			}
+		}
+		return NewSetMatcher(m.Values...), nil
+
+	case "range":
+		if m.Min == nil && m.Max == nil {
+			return nil, fmt.Errorf("min or max is required for \"range\" matcher")
+		}
+		min, max := math.Inf(-1), math.Inf(1)
+		if m.Min != nil {
+			min = *m.Min
+		}
+		if m.Max != nil {
+			max = *m.Max
+		}
+		if min > max {
+			return nil, fmt.Errorf("min %v is greater than max %v", min, max)
+		}
+		return NewRangeMatcher(min, max), nil
+
+	case "and", "or", "not":
+		if len(m.Matchers) == 0 {
+			return nil, fmt.Errorf("matchers are required for %q matcher", m.Matcher)
+		}
+		if m.Matcher == "not" && len(m.Matchers) != 1 {
+			return nil, fmt.Errorf("\"not\" matcher takes exactly one matcher, got %d", len(m.Matchers))
+		}
+
+		matchers := make([]Matcher, 0, len(m.Matchers))
+		for _, cfg := range m.Matchers {
+			matcher, err := cfg.NewMatcher()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", m.Matcher, err)
+			}
+			matchers = append(matchers, matcher)
+		}
+
+		switch m.Matcher {
+		case "and":
+			return NewAndMatcher(matchers...), nil
+		case "or":
+			return NewOrMatcher(matchers...), nil
+		default:
+			return NewNotMatcher(matchers[0]), nil
+		}
+
+	case "all":
+		return &MatchAllMatcher{}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown matcher %q", m.Matcher)
+	}
+}
+
+// Returns error for values which can't be compared with tag values (lists, maps).
+func checkScalar(value interface{}) error {
+	switch value.(type) {
+	case string, bool:
+		return nil
+	}
+	if _, ok := toFloat64(value); ok {
+		return nil
+	}
+	return fmt.Errorf("value must be string, number or boolean, got %v", value)
+}
+
+func parseDecision(decision string) (SamplingDecision, error) {
+	switch decision {
+	case "take":
+		return DecisionTake, nil
+	case "drop":
+		return DecisionDrop, nil
+	case "next":
+		return DecisionNextSampler, nil
+	default:
+		return DecisionNextSampler, fmt.Errorf("unknown decision %q (expected take, drop or next)", decision)
+	}
+}
@@ -0,0 +1,121 @@
+package opentracing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleIdsDontDependOnPosition(t *testing.T) {
+	health := TagMatch{Tag: "path", Matcher: NewStringRegexpMatcher(`^/health`), Decision: DecisionDrop}
+	failed := TagMatch{Tag: "http.status_code", Matcher: NewMinMatcher(500), Decision: DecisionTake}
+	debug := TagMatch{Tag: "debug", Matcher: &MatchAllMatcher{}, Decision: DecisionTake}
+
+	sampler := NewTagSampler([]TagMatch{health, failed})
+	before := sampler.Stats()
+
+	// NOTE: reload with new rule in front moves other rules
+	if err := sampler.SetMatches([]TagMatch{debug, health, failed}); err != nil {
+		t.Fatal(err)
+	}
+	after := sampler.Stats()
+
+	if after[1].ID != before[0].ID || after[2].ID != before[1].ID {
+		t.Fatalf("ids of rules are changed after reload: %v -> %v", before, after)
+	}
+	if !strings.HasPrefix(after[0].ID, "debug#") {
+		t.Fatalf("expected id with tag prefix, got %q", after[0].ID)
+	}
+}
+
+func TestRuleIdsDependOnContent(t *testing.T) {
+	matches := []TagMatch{
+		{Tag: "path", Matcher: NewStringRegexpMatcher(`^/health`), Decision: DecisionDrop},
+		{Tag: "path", Matcher: NewStringRegexpMatcher(`^/health`), Decision: DecisionTake},
+		{Tag: "path", Matcher: NewStringRegexpMatcher(`^/metrics`), Decision: DecisionDrop},
+		{Tag: "path", Matcher: NewOrMatcher(NewPrefixMatcher("/a"), NewSuffixMatcher("b")), Decision: DecisionDrop},
+		{Tag: "path", Matcher: NewOrMatcher(NewPrefixMatcher("/a"), NewSuffixMatcher("c")), Decision: DecisionDrop},
+		{Tag: "code", Matcher: NewEqualMatcher(500), Decision: DecisionDrop},
+		{Tag: "code", Matcher: NewEqualMatcher("500"), Decision: DecisionDrop},
+		{Tag: "code", Matcher: NewSetMatcher(500, 501), Decision: DecisionDrop},
+		{Name: "named", Tag: "code", Matcher: NewSetMatcher(500, 501), Decision: DecisionDrop},
+	}
+
+	ids := make(map[string]bool)
+	for _, stats := range NewTagSampler(matches).Stats() {
+		if ids[stats.ID] {
+			t.Fatalf("duplicate id %q", stats.ID)
+		}
+		ids[stats.ID] = true
+	}
+
+	if !ids["named"] {
+		t.Fatalf("expected name to be used as id, got %v", ids)
+	}
+}
+
+func TestHitsAreKeptAfterReload(t *testing.T) {
+	fallback, _ := NewProbabilisticFallbackSampler(0)
+	health := TagMatch{Tag: "path", Matcher: NewStringRegexpMatcher(`^/health`), Decision: DecisionDrop}
+	users := TagMatch{Tag: "path", Matcher: NewStringRegexpMatcher(`^/users`), Decision: DecisionTake}
+
+	sampler := NewTagSampler([]TagMatch{health, users})
+	tracer, _ := newTestTracer(t, NewChainSampler(sampler, fallback))
+
+	span := tracer.StartSpan("request")
+	span.SetTag("path", "/health")
+	span.Finish()
+
+	if err := sampler.SetMatches([]TagMatch{users, health}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := sampler.Stats()
+	if stats[0].Hits != 0 || stats[1].Hits != 1 {
+		t.Fatalf("expected hit to stay with health rule, got %+v", stats)
+	}
+}
+
+func TestSetMatchesRejectsDuplicateNames(t *testing.T) {
+	sampler := NewTagSampler([]TagMatch{{Name: "a", Tag: "path", Matcher: &MatchAllMatcher{}, Decision: DecisionDrop}})
+
+	err := sampler.SetMatches([]TagMatch{
+		{Name: "b", Tag: "path", Matcher: &MatchAllMatcher{}, Decision: DecisionDrop},
+		{Name: "b", Tag: "error", Matcher: NewBoolMatcher(true), Decision: DecisionTake},
+	})
+	if err == nil {
+		t.Fatalf("expected error for duplicate names")
+	}
+
+	if stats := sampler.Stats(); len(stats) != 1 || stats[0].ID != "a" {
+		t.Fatalf("expected rules to stay the same, got %+v", stats)
+	}
+}
+
+func TestNewTagSamplerWithDuplicateNames(t *testing.T) {
+	matches := []TagMatch{
+		{Name: "a", Tag: "path", Matcher: &MatchAllMatcher{}, Decision: DecisionDrop},
+		{Name: "a", Tag: "path", Matcher: &MatchAllMatcher{}, Decision: DecisionTake},
+	}
+
+	if _, err := NewTagSamplerE(matches); err == nil {
+		t.Fatal("expected error for duplicate names")
+	}
+
+	stats := NewTagSampler(matches).Stats()
+	if len(stats) != 2 || stats[0].ID != "a" || !strings.HasPrefix(stats[1].ID, "path#") {
+		t.Fatalf("expected both rules with id built from content for duplicate, got %+v", stats)
+	}
+}
+
+func TestStatsDescribeMatchers(t *testing.T) {
+	sampler := NewTagSampler([]TagMatch{{
+		Tag:      "http.method",
+		Matcher:  NewNotMatcher(NewSetMatcher("GET", "HEAD")),
+		Decision: DecisionTake,
+	}})
+
+	expected := "not(in(string(GET), string(HEAD)))"
+	if matcher := sampler.Stats()[0].Matcher; matcher != expected {
+		t.Fatalf("expected matcher %q, got %q", expected, matcher)
+	}
+}
@@ -0,0 +1,60 @@
+package opentracing
+
+import (
+	"net/http"
+
+	"github.com/kataras/iris/v12/context"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// Tags which are not part of opentracing-go/ext.
+const (
+	tagHTTPRoute     = "http.route"
+	tagHTTPUserAgent = "http.user_agent"
+	tagPeerIP        = "peer.ip"
+)
+
+// Returns true for response statuses which mark span as error (5xx).
+func DefaultErrorStatus(status int) bool {
+	return status >= http.StatusInternalServerError
+}
+
+// Sets semantic convention tags of request.
+// SEE: https://github.com/opentracing/specification/blob/master/semantic_conventions.md
+func setRequestTags(span opentracing.Span, ctx context.Context) {
+	ext.SpanKindRPCServer.Set(span)
+	ext.Component.Set(span, "iris")
+	ext.HTTPMethod.Set(span, ctx.Method())
+	ext.HTTPUrl.Set(span, requestURL(ctx.Request()))
+
+	if route := currentRoute(ctx); route != nil {
+		span.SetTag(tagHTTPRoute, route.Path())
+	}
+
+	span.SetTag(tagPeerIP, ctx.RemoteAddr()).
+		SetTag(tagHTTPUserAgent, ctx.GetHeader("User-Agent"))
+
+	// NOTE: old tags are kept for existing TagSampler rules
+	span.SetTag("path", ctx.Path()).
+		SetTag("method", ctx.Method())
+}
+
+// Sets tags of response, it must be called after ctx.Next().
+func setResponseTags(span opentracing.Span, ctx context.Context, isError func(status int) bool) {
+	status := ctx.GetStatusCode()
+
+	ext.HTTPStatusCode.Set(span, uint16(status))
+
+	if isError(status) {
+		ext.Error.Set(span, true)
+	}
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
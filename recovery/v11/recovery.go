@@ -0,0 +1,213 @@
+package recovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/kataras/iris/context"
+	"github.com/ont/iris-related/logging/v11"
+	"github.com/ont/iris-related/requestid/v11"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Format of 500 response body.
+type Format string
+
+const (
+	FormatProblemJSON Format = "problem-json" // RFC 7807 problem details (default)
+	FormatHTML        Format = "html"
+)
+
+// Function which is called for each recovered panic (for example to report it to error tracker).
+type Hook func(ctx context.Context, err error, stack []byte)
+
+// Function which writes response for recovered panic.
+type Responder func(ctx context.Context, err error)
+
+// Recovery middleware which turns panics of next handlers into 500 responses.
+// Install it after tracing and logging middlewares, so span and logger of the request are available.
+// Usage:
+//
+//	app.UseGlobal(t.Handler)
+//	app.UseGlobal(requestid.Middleware)
+//	l := logging.New(logger)
+//	l.Register(app)
+//	app.UseGlobal(l.Handler)
+//	app.UseGlobal(recovery.New(recovery.WithHook(reportToSentry)).Handler)
+type Recovery struct {
+	format    Format
+	responder Responder
+	hooks     []Hook
+	repanic   bool
+}
+
+type Option func(r *Recovery)
+
+// Sets format of 500 response body (default is FormatProblemJSON).
+func WithFormat(format Format) Option {
+	return func(r *Recovery) {
+		r.format = format
+	}
+}
+
+// Sets function which writes response instead of built-in formats.
+func WithResponder(responder Responder) Option {
+	return func(r *Recovery) {
+		r.responder = responder
+	}
+}
+
+// Adds hook which is called for each recovered panic.
+func WithHook(hook Hook) Option {
+	return func(r *Recovery) {
+		r.hooks = append(r.hooks, hook)
+	}
+}
+
+// Sets whether panic is raised again after response is written (default is false).
+// It lets outer middlewares or http server see the panic.
+func WithRepanic(repanic bool) Option {
+	return func(r *Recovery) {
+		r.repanic = repanic
+	}
+}
+
+// Creates recovery middleware.
+func New(opts ...Option) *Recovery {
+	r := &Recovery{
+		format: FormatProblemJSON,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.responder == nil {
+		switch r.format {
+		case FormatHTML:
+			r.responder = respondHTML
+		default:
+			r.responder = respondProblemJSON
+		}
+	}
+
+	return r
+}
+
+// Middleware handler which recovers panics of next handlers.
+func (r *Recovery) Handler(ctx context.Context) {
+	// NOTE: headers of previous middlewares (for example request id) are kept in 500 response
+	headers := ctx.ResponseWriter().Header().Clone()
+
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		// NOTE: http.ErrAbortHandler is used to abort response silently
+		if rec == http.ErrAbortHandler {
+			panic(rec)
+		}
+
+		err, ok := rec.(error)
+		if !ok {
+			err = fmt.Errorf("%v", rec)
+		}
+
+		stack := debug.Stack()
+
+		logging.Get(ctx).
+			WithError(err).
+			WithField("stack", string(stack)).
+			Errorf("Panic recovered in %s %s", ctx.Method(), ctx.Path())
+
+		if span := opentracing.SpanFromContext(ctx.Request().Context()); span != nil {
+			span.SetTag("error", true).
+				SetTag("panic", true).
+				LogKV(
+					"event", "panic",
+					"error", err.Error(),
+					"stack", string(stack),
+				)
+		}
+
+		for _, hook := range r.hooks {
+			hook(ctx, err, stack)
+		}
+
+		ctx.StopExecution()
+
+		// NOTE: response can't be replaced when handler already sent part of it
+		if ctx.ResponseWriter().Written() == context.NoWritten {
+			resetHeaders(ctx.ResponseWriter().Header(), headers)
+
+			recorder, recording := ctx.IsRecording()
+			if recording {
+				recorder.ResetBody()
+			}
+
+			ctx.StatusCode(http.StatusInternalServerError)
+			r.responder(ctx, err)
+
+			// NOTE: iris fires its own error handler for error status when nothing was sent yet,
+			//       recorder holds response until the end of request, so it is sent right away
+			//       (middlewares which recorded response see empty body)
+			if recording {
+				recorder.FlushResponse()
+				recorder.ResetBody()
+			}
+		}
+
+		if r.repanic {
+			panic(rec)
+		}
+	}()
+
+	ctx.Next()
+}
+
+// Replaces headers of response with saved ones.
+func resetHeaders(header http.Header, saved http.Header) {
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range saved {
+		header[key] = values
+	}
+}
+
+// SEE: https://tools.ietf.org/html/rfc7807
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Instance  string `json:"instance,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+func respondProblemJSON(ctx context.Context, err error) {
+	body, _ := json.Marshal(problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(http.StatusInternalServerError),
+		Status:    http.StatusInternalServerError,
+		Instance:  ctx.Path(),
+		RequestId: requestid.Get(ctx),
+	})
+
+	ctx.ContentType("application/problem+json")
+	ctx.Write(body)
+}
+
+func respondHTML(ctx context.Context, err error) {
+	title := http.StatusText(http.StatusInternalServerError)
+
+	ctx.ContentType("text/html")
+	ctx.WriteString(fmt.Sprintf(
+		"<!DOCTYPE html><html><head><title>500 %s</title></head><body><h1>%s</h1><p>Request ID: %s</p></body></html>",
+		title, title, html.EscapeString(requestid.Get(ctx)),
+	))
+}
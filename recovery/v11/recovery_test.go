@@ -0,0 +1,132 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris"
+	"github.com/ont/iris-related/etag/v11"
+	"github.com/ont/iris-related/logging/v11"
+	"github.com/sirupsen/logrus"
+)
+
+// Returns app with given middlewares before recovery one and routes which panic.
+func newApp(t *testing.T, r *Recovery, middlewares ...iris.Handler) *iris.Application {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	app := iris.New()
+	app.UseGlobal(logging.New(logger).Handler)
+	for _, middleware := range middlewares {
+		app.UseGlobal(middleware)
+	}
+	app.UseGlobal(r.Handler)
+
+	app.Get("/panic", func(ctx iris.Context) {
+		ctx.StatusCode(http.StatusCreated)
+		panic("boom")
+	})
+	app.Get("/partial", func(ctx iris.Context) {
+		ctx.Header("X-Partial", "yes")
+		ctx.WriteString("partial")
+		panic("boom")
+	})
+
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func serve(app *iris.Application, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func checkProblem(t *testing.T, w *httptest.ResponseRecorder, instance string) {
+	t.Helper()
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Fatalf("expected problem+json content type, got %q (body %q)", ct, w.Body.String())
+	}
+
+	var p problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("invalid body %q: %s", w.Body.String(), err)
+	}
+	if p.Status != http.StatusInternalServerError || p.Instance != instance {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+}
+
+func TestHandlerRespondsProblemJSON(t *testing.T) {
+	cases := []struct {
+		name        string
+		middlewares []iris.Handler
+	}{
+		{"without recorder", nil},
+		{"with etag.Record", []iris.Handler{etag.Record}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			app := newApp(t, New(), c.middlewares...)
+
+			checkProblem(t, serve(app, "/panic"), "/panic")
+		})
+	}
+}
+
+func TestHandlerReplacesRecordedResponse(t *testing.T) {
+	before := func(ctx iris.Context) {
+		ctx.Header("X-Before", "yes")
+		ctx.Next()
+	}
+	app := newApp(t, New(), before, etag.Record)
+
+	w := serve(app, "/partial")
+
+	checkProblem(t, w, "/partial")
+	if w.Header().Get("X-Partial") != "" {
+		t.Fatalf("headers of failed handler are sent")
+	}
+	if w.Header().Get("X-Before") != "yes" {
+		t.Fatalf("headers of previous middlewares are lost")
+	}
+}
+
+func TestHandlerKeepsSentResponse(t *testing.T) {
+	app := newApp(t, New())
+
+	w := serve(app, "/partial")
+
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Fatalf("expected sent response to stay as is, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHandlerRespondsHTML(t *testing.T) {
+	for _, middlewares := range [][]iris.Handler{nil, {etag.Record}} {
+		app := newApp(t, New(WithFormat(FormatHTML)), middlewares...)
+
+		w := serve(app, "/panic")
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Fatalf("expected html content type, got %q", ct)
+		}
+		if !strings.Contains(w.Body.String(), "<h1>Internal Server Error</h1>") {
+			t.Fatalf("unexpected body %q", w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package recovery
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kataras/iris/v12"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+// Error tracker stub which keeps reported panics.
+type tracker struct {
+	mu     sync.Mutex
+	errs   []error
+	stacks [][]byte
+	paths  []string
+}
+
+func (tr *tracker) report(ctx iris.Context, err error, stack []byte) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.errs = append(tr.errs, err)
+	tr.stacks = append(tr.stacks, stack)
+	tr.paths = append(tr.paths, ctx.Path())
+}
+
+// Returns middleware which starts mock span for each request.
+func spanMiddleware(tracer *mocktracer.MockTracer) iris.Handler {
+	return func(ctx iris.Context) {
+		span := tracer.StartSpan("request")
+		defer span.Finish()
+
+		ctx.ResetRequest(ctx.Request().WithContext(opentracing.ContextWithSpan(ctx.Request().Context(), span)))
+		ctx.Next()
+	}
+}
+
+func TestHookReceivesErrorAndStack(t *testing.T) {
+	tr := &tracker{}
+	app := newApp(t, New(WithHook(tr.report)))
+
+	serve(app, "/panic")
+
+	if len(tr.errs) != 1 {
+		t.Fatalf("expected hook to be called once, got %d calls", len(tr.errs))
+	}
+	if tr.errs[0].Error() != "boom" {
+		t.Fatalf("expected error of panic, got %q", tr.errs[0])
+	}
+	if !strings.Contains(string(tr.stacks[0]), "recovery_test.go") {
+		t.Fatalf("expected stack of panicked handler, got %s", tr.stacks[0])
+	}
+	if tr.paths[0] != "/panic" {
+		t.Fatalf("expected context of request, got path %q", tr.paths[0])
+	}
+}
+
+func TestHookReceivesPanickedError(t *testing.T) {
+	tr := &tracker{}
+	errBoom := errors.New("boom")
+
+	app := iris.New()
+	app.UseGlobal(New(WithHook(tr.report)).Handler)
+	app.Get("/", func(ctx iris.Context) {
+		panic(errBoom)
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	serve(app, "/")
+
+	if len(tr.errs) != 1 || tr.errs[0] != errBoom {
+		t.Fatalf("expected panicked error to be passed as is, got %v", tr.errs)
+	}
+}
+
+func TestHandlerMarksSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	app := newApp(t, New(), spanMiddleware(tracer))
+
+	serve(app, "/panic")
+
+	spans := tracer.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Tag("error") != true || span.Tag("panic") != true {
+		t.Fatalf("expected error and panic tags, got %v", span.Tags())
+	}
+
+	logs := span.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("expected panic log, got %d logs", len(logs))
+	}
+	fields := make(map[string]string)
+	for _, field := range logs[0].Fields {
+		fields[field.Key] = field.ValueString
+	}
+	if fields["event"] != "panic" || fields["error"] != "boom" || fields["stack"] == "" {
+		t.Fatalf("unexpected panic log %v", fields)
+	}
+}
+
+func TestRepanic(t *testing.T) {
+	var repanicked interface{}
+	outer := func(ctx iris.Context) {
+		defer func() {
+			repanicked = recover()
+		}()
+		ctx.Next()
+	}
+
+	tr := &tracker{}
+	app := newApp(t, New(WithHook(tr.report), WithRepanic(true)), outer)
+
+	w := serve(app, "/panic")
+
+	if repanicked != "boom" {
+		t.Fatalf("expected panic to be raised again, got %v", repanicked)
+	}
+	if len(tr.errs) != 1 {
+		t.Fatalf("expected hook to be called before repanic, got %d calls", len(tr.errs))
+	}
+	checkProblem(t, w, "/panic")
+}
+
+func TestRepanicIsDisabledByDefault(t *testing.T) {
+	var repanicked interface{}
+	outer := func(ctx iris.Context) {
+		defer func() {
+			repanicked = recover()
+		}()
+		ctx.Next()
+	}
+
+	app := newApp(t, New(), outer)
+
+	if w := serve(app, "/panic"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500, got %d", w.Code)
+	}
+	if repanicked != nil {
+		t.Fatalf("expected panic to be recovered, got %v", repanicked)
+	}
+}
+
+func TestAbortHandlerIsNotRecovered(t *testing.T) {
+	tr := &tracker{}
+
+	app := iris.New()
+	app.UseGlobal(New(WithHook(tr.report)).Handler)
+	app.Get("/", func(ctx iris.Context) {
+		panic(http.ErrAbortHandler)
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler to be raised again, got %v", rec)
+		}
+		if len(tr.errs) != 0 {
+			t.Fatalf("expected hook not to be called for http.ErrAbortHandler")
+		}
+	}()
+	serve(app, "/")
+}
@@ -0,0 +1,97 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Generator returns new unique request-id.
+type Generator func() string
+
+// Generates random md5-like string (32 hex chars from crypto/rand).
+func Hex() string {
+	return hex.EncodeToString(randBytes(16))
+}
+
+// Generates random UUID version 4 (RFC 4122).
+func UUIDv4() string {
+	b := randBytes(16)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+	return formatUUID(b)
+}
+
+// Generates time-ordered UUID version 7 (48 bits of unix milliseconds + random).
+func UUIDv7() string {
+	b := randBytes(16)
+	putMillis(b, time.Now())
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+	return formatUUID(b)
+}
+
+// Generates ULID: 48 bits of unix milliseconds + 80 random bits in Crockford's base32.
+// SEE: https://github.com/ulid/spec
+func ULID() string {
+	b := randBytes(16)
+	putMillis(b, time.Now())
+	return encodeBase(b, crockfordAlphabet, 26)
+}
+
+// Generates KSUID: 32 bits of seconds since KSUID epoch + 128 random bits in base62.
+// SEE: https://github.com/segmentio/ksuid
+func KSUID() string {
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint32(b, uint32(time.Now().Unix()-ksuidEpoch))
+	copy(b[4:], randBytes(16))
+	return encodeBase(b, base62Alphabet, 27)
+}
+
+const (
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base62Alphabet    = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEpoch        = 1400000000
+)
+
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// NOTE: crypto/rand fails only on broken systems, there is no sane fallback
+		panic(fmt.Sprintf("requestid: can't read random bytes: %s", err))
+	}
+	return b
+}
+
+// Writes unix milliseconds into first 6 bytes of b.
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+}
+
+func formatUUID(b []byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Encodes b as big-endian number in given alphabet, left padded to width.
+func encodeBase(b []byte, alphabet string, width int) string {
+	num := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+
+	out := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		num.DivMod(num, base, mod)
+		out[i] = alphabet[mod.Int64()]
+	}
+
+	return string(out)
+}
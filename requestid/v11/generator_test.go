@@ -0,0 +1,60 @@
+package requestid
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGenerators(t *testing.T) {
+	cases := []struct {
+		name      string
+		generator Generator
+		pattern   string
+	}{
+		{"Hex", Hex, `^[0-9a-f]{32}$`},
+		{"UUIDv4", UUIDv4, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"UUIDv7", UUIDv7, `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"ULID", ULID, `^[0-9A-HJKMNP-TV-Z]{26}$`},
+		{"KSUID", KSUID, `^[0-9A-Za-z]{27}$`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			re := regexp.MustCompile(c.pattern)
+
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				id := c.generator()
+				if !re.MatchString(id) {
+					t.Fatalf("%q doesn't match %s", id, c.pattern)
+				}
+				if seen[id] {
+					t.Fatalf("duplicate id %q", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestTimeOrderedGenerators(t *testing.T) {
+	for name, generator := range map[string]Generator{"UUIDv7": UUIDv7, "ULID": ULID} {
+		first := generator()
+		time.Sleep(2 * time.Millisecond)
+		second := generator()
+
+		if first >= second {
+			t.Fatalf("%s: expected %q < %q", name, first, second)
+		}
+	}
+}
+
+func TestEncodeBase(t *testing.T) {
+	if s := encodeBase([]byte{0, 0}, base62Alphabet, 3); s != "000" {
+		t.Fatalf("expected zero padded value, got %q", s)
+	}
+	if s := encodeBase([]byte{0, 62}, base62Alphabet, 3); s != "010" {
+		t.Fatalf("expected 62 to be encoded as \"010\", got %q", s)
+	}
+}
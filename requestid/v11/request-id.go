@@ -1,37 +1,217 @@
 package requestid
 
 import (
-	"encoding/hex"
+	"net/http"
+
+	gocontext "context"
+
 	"github.com/kataras/iris"
-	"math/rand"
 )
 
-// Returns request-id from context.
+const (
+	// Default max length of incoming request-id.
+	DefaultMaxLength = 128
+
+	// Default set of chars allowed in incoming request-id.
+	// NOTE: it doesn't contain spaces, quotes and control chars to prevent log injection.
+	DefaultCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.:"
+)
+
+// What to do with incoming request-id which failed validation.
+type InvalidPolicy int
+
+const (
+	InvalidReplace InvalidPolicy = iota // replace with newly generated request-id
+	InvalidReject                       // respond with 400 Bad Request
+)
+
+type options struct {
+	headers        []string
+	responseHeader string
+	generator      Generator
+	maxLength      int
+	allowed        [256]bool
+	invalid        InvalidPolicy
+	fromTrace      bool
+}
+
+type Option func(o *options)
+
+// Sets incoming headers to take request-id from, in priority order (default is "X-Request-Id").
+// For example: requestid.WithHeaders("X-Request-Id", "X-Correlation-Id", "Request-Id")
+func WithHeaders(names ...string) Option {
+	return func(o *options) {
+		o.headers = names
+	}
+}
+
+// Sets response header to echo request-id in (default is "X-Request-Id").
+// Empty name disables echoing.
+func WithResponseHeader(name string) Option {
+	return func(o *options) {
+		o.responseHeader = name
+	}
+}
+
+// Sets generator for new request-ids (default is Hex).
+func WithGenerator(g Generator) Option {
+	return func(o *options) {
+		o.generator = g
+	}
+}
+
+// Sets max length and allowed chars of incoming request-id.
+func WithValidation(maxLength int, charset string) Option {
+	return func(o *options) {
+		o.maxLength = maxLength
+		o.allowed = [256]bool{}
+		for i := 0; i < len(charset); i++ {
+			o.allowed[charset[i]] = true
+		}
+	}
+}
+
+// Enables or disables taking request-id from trace-id of incoming W3C "traceparent"
+// or jaeger "uber-trace-id" headers when there is no explicit request-id (disabled by default).
+// This way logs and traces of the request share the same id, but request-ids of callers which send
+// trace headers without request-id header change, so it is opt-in.
+func WithTraceFallback(enabled bool) Option {
+	return func(o *options) {
+		o.fromTrace = enabled
+	}
+}
+
+// Sets what to do with invalid incoming request-id (default is InvalidReplace).
+func WithInvalidPolicy(policy InvalidPolicy) Option {
+	return func(o *options) {
+		o.invalid = policy
+	}
+}
+
+// Keys of values stored in iris context by this package (read them by Get/Lookup/GetSource).
+// NOTE: iris values are keyed by plain strings, so keys are namespaced by package name.
+const (
+	requestIdKey = "requestid.request-id"
+	sourceKey    = "requestid.source"
+)
+
+// Key of request-id in go context.
+type contextKey struct{}
+
+var defaultMiddleware = New()
+
+// Returns request-id from context and false if Middleware wasn't installed.
+func Lookup(ctx iris.Context) (string, bool) {
+	requestId, ok := ctx.Values().Get(requestIdKey).(string)
+	return requestId, ok
+}
+
+// Returns request-id from context or empty string if Middleware wasn't installed.
 func Get(ctx iris.Context) string {
-	return ctx.Values().Get("request-id").(string)
+	requestId, _ := Lookup(ctx)
+	return requestId
+}
+
+// Returns where request-id was taken from or empty string if Middleware wasn't installed.
+func GetSource(ctx iris.Context) Source {
+	source, _ := ctx.Values().Get(sourceKey).(Source)
+	return source
+}
+
+// Returns copy of go context with request-id attached.
+// Use it to pass request-id into code which doesn't know about iris.
+func NewContext(ctx gocontext.Context, requestId string) gocontext.Context {
+	return gocontext.WithValue(ctx, contextKey{}, requestId)
+}
+
+// Returns request-id from go context and false if there is no one.
+// Middleware attaches request-id to the context of each request (see ctx.Request().Context()).
+func FromContext(ctx gocontext.Context) (string, bool) {
+	requestId, ok := ctx.Value(contextKey{}).(string)
+	return requestId, ok
 }
 
 // Use this function as middleware for iris.
 // For example: app.Use(requestid.Middleware)
 func Middleware(ctx iris.Context) {
-	requestId := getRequestId(ctx)
-	ctx.Values().Set("request-id", requestId)
-	ctx.Next() // all ok, call other middlewares
+	defaultMiddleware(ctx)
 }
 
-func getRequestId(ctx iris.Context) string {
-	value := ctx.GetHeader("X-Request-Id")
+// Creates configured middleware.
+// For example: app.Use(requestid.New(requestid.WithGenerator(requestid.UUIDv7)))
+func New(opts ...Option) iris.Handler {
+	o := &options{
+		headers:        []string{"X-Request-Id"},
+		responseHeader: "X-Request-Id",
+		generator:      Hex,
+	}
+	WithValidation(DefaultMaxLength, DefaultCharset)(o)
 
-	if value == "" {
-		value = genRand()
+	for _, opt := range opts {
+		opt(o)
 	}
 
-	return value
+	return func(ctx iris.Context) {
+		requestId, source := o.incoming(ctx)
+
+		if requestId != "" && !o.valid(requestId) {
+			if o.invalid == InvalidReject {
+				ctx.StatusCode(http.StatusBadRequest)
+				ctx.StopExecution()
+				return
+			}
+			requestId = ""
+		}
+
+		if requestId == "" {
+			requestId, source = o.generator(), SourceGenerated
+		}
+
+		ctx.Values().Set(requestIdKey, requestId)
+		ctx.Values().Set(sourceKey, source)
+		// NOTE: iris v11 doesn't have ctx.ResetRequest, so request is replaced in place
+		r := ctx.Request()
+		*r = *r.WithContext(NewContext(r.Context(), requestId))
+
+		if o.responseHeader != "" {
+			ctx.Header(o.responseHeader, requestId)
+		}
+
+		ctx.Next() // all ok, call other middlewares
+	}
+}
+
+// Returns value of the first non-empty incoming header.
+// Falls back to trace-id of incoming trace headers (if enabled).
+func (o *options) incoming(ctx iris.Context) (string, Source) {
+	for _, name := range o.headers {
+		if value := ctx.GetHeader(name); value != "" {
+			return value, SourceHeader
+		}
+	}
+
+	if o.fromTrace {
+		if traceId, ok := parseTraceparent(ctx.GetHeader("traceparent")); ok {
+			return traceId, SourceTraceparent
+		}
+		if traceId, ok := parseUberTraceId(ctx.GetHeader("uber-trace-id")); ok {
+			return traceId, SourceJaeger
+		}
+	}
+
+	return "", ""
 }
 
-// Generates random md5-like string.
-func genRand() string {
-	bytes := make([]byte, 16)
-	rand.Read(bytes)
-	return hex.EncodeToString(bytes) + "-generated"
+func (o *options) valid(value string) bool {
+	if len(value) > o.maxLength {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if !o.allowed[value[i]] {
+			return false
+		}
+	}
+
+	return true
 }
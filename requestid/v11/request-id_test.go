@@ -0,0 +1,149 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/iris"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// Returns response, request-id and its source which handler got for request with given headers.
+func serve(t *testing.T, middleware iris.Handler, header http.Header) (*httptest.ResponseRecorder, string, Source) {
+	var id string
+	var source Source
+
+	app := iris.New()
+	app.Use(middleware)
+	app.Get("/", func(ctx iris.Context) {
+		id, source = Get(ctx), GetSource(ctx)
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	return w, id, source
+}
+
+// Returns request-id and its source which handler got for request with given headers.
+func requestId(t *testing.T, middleware iris.Handler, header http.Header) (string, Source) {
+	_, id, source := serve(t, middleware, header)
+	return id, source
+}
+
+func TestTraceFallbackIsDisabledByDefault(t *testing.T) {
+	header := http.Header{"Traceparent": {traceparent}}
+
+	for name, middleware := range map[string]iris.Handler{"Middleware": Middleware, "New": New()} {
+		id, source := requestId(t, middleware, header)
+		if source != SourceGenerated || id == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("%s: expected generated request-id, got %q from %s", name, id, source)
+		}
+	}
+}
+
+func TestTraceFallback(t *testing.T) {
+	cases := []struct {
+		header http.Header
+		id     string
+		source Source
+	}{
+		{http.Header{"Traceparent": {traceparent}}, "4bf92f3577b34da6a3ce929d0e0e4736", SourceTraceparent},
+		{http.Header{"Uber-Trace-Id": {"a3ce929d0e0e4736:00f067aa0ba902b7:0:1"}}, "a3ce929d0e0e4736", SourceJaeger},
+		{http.Header{"Traceparent": {traceparent}, "X-Request-Id": {"req-1"}}, "req-1", SourceHeader},
+	}
+
+	middleware := New(WithTraceFallback(true))
+	for _, c := range cases {
+		id, source := requestId(t, middleware, c.header)
+		if id != c.id || source != c.source {
+			t.Errorf("headers %v: expected %q from %s, got %q from %s", c.header, c.id, c.source, id, source)
+		}
+	}
+}
+
+func TestInvalidRequestIdIsReplaced(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{"too long", strings.Repeat("a", DefaultMaxLength+1)},
+		{"bad charset", "req 1\" level=error"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w, id, source := serve(t, New(), http.Header{"X-Request-Id": {c.value}})
+
+			if id == c.value || source != SourceGenerated {
+				t.Fatalf("expected generated request-id, got %q from %s", id, source)
+			}
+			if echo := w.Header().Get("X-Request-Id"); echo != id {
+				t.Fatalf("expected generated request-id %q in response, got %q", id, echo)
+			}
+		})
+	}
+
+	valid := strings.Repeat("a", DefaultMaxLength)
+	if id, source := requestId(t, New(), http.Header{"X-Request-Id": {valid}}); id != valid || source != SourceHeader {
+		t.Fatalf("expected request-id of max length to be kept, got %q from %s", id, source)
+	}
+}
+
+func TestInvalidRequestIdIsRejected(t *testing.T) {
+	w, id, _ := serve(t, New(WithInvalidPolicy(InvalidReject)), http.Header{"X-Request-Id": {"req 1"}})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if id != "" {
+		t.Fatalf("expected handler not to be called, it got request-id %q", id)
+	}
+}
+
+func TestHeadersPriority(t *testing.T) {
+	cases := []struct {
+		header http.Header
+		id     string
+	}{
+		{http.Header{"X-Request-Id": {"req-1"}, "X-Correlation-Id": {"corr-1"}, "Request-Id": {"plain-1"}}, "req-1"},
+		{http.Header{"X-Correlation-Id": {"corr-1"}, "Request-Id": {"plain-1"}}, "corr-1"},
+		{http.Header{"Request-Id": {"plain-1"}}, "plain-1"},
+	}
+
+	middleware := New(WithHeaders("X-Request-Id", "X-Correlation-Id", "Request-Id"))
+	for _, c := range cases {
+		id, source := requestId(t, middleware, c.header)
+		if id != c.id || source != SourceHeader {
+			t.Errorf("headers %v: expected %q from %s, got %q from %s", c.header, c.id, SourceHeader, id, source)
+		}
+	}
+}
+
+func TestResponseHeader(t *testing.T) {
+	w, id, _ := serve(t, New(WithResponseHeader("X-Correlation-Id")), nil)
+	if echo := w.Header().Get("X-Correlation-Id"); echo != id {
+		t.Fatalf("expected request-id %q in X-Correlation-Id, got %q", id, echo)
+	}
+	if echo := w.Header().Get("X-Request-Id"); echo != "" {
+		t.Fatalf("expected request-id to be echoed only in configured header, got X-Request-Id %q", echo)
+	}
+
+	w, id, _ = serve(t, New(WithResponseHeader("")), http.Header{"X-Request-Id": {"req-1"}})
+	if id != "req-1" {
+		t.Fatalf("expected request-id from header, got %q", id)
+	}
+	if echo := w.Header().Get("X-Request-Id"); echo != "" {
+		t.Fatalf("expected empty response header to disable echoing, got X-Request-Id %q", echo)
+	}
+}
@@ -0,0 +1,78 @@
+package requestid
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Where request-id was taken from.
+type Source string
+
+const (
+	SourceHeader      Source = "header"        // one of configured request-id headers
+	SourceTraceparent Source = "traceparent"   // trace-id of W3C "traceparent" header
+	SourceJaeger      Source = "uber-trace-id" // trace-id of jaeger "uber-trace-id" header
+	SourceGenerated   Source = "generated"     // newly generated by Generator
+)
+
+// Returns trace-id from W3C traceparent header value.
+// SEE: https://www.w3.org/TR/trace-context/#traceparent-header
+func parseTraceparent(value string) (string, bool) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", false
+	}
+
+	version, traceId := parts[0], parts[1]
+	if len(version) != 2 || !isHex(version) || version == "ff" {
+		return "", false
+	}
+
+	// NOTE: version 00 has exactly 4 fields, future versions may add more
+	if version == "00" && len(parts) != 4 {
+		return "", false
+	}
+
+	if len(traceId) != 32 || !isHex(traceId) || isZero(traceId) {
+		return "", false
+	}
+
+	return traceId, true
+}
+
+// Returns trace-id from jaeger uber-trace-id header value.
+// SEE: https://www.jaegertracing.io/docs/1.21/client-libraries/#tracespan-identity
+func parseUberTraceId(value string) (string, bool) {
+	// NOTE: jaeger clients may send url-encoded value
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+
+	// trace-id ":" span-id ":" parent-span-id ":" flags
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 4 {
+		return "", false
+	}
+
+	traceId := strings.ToLower(parts[0])
+	if len(traceId) == 0 || len(traceId) > 32 || !isHex(traceId) || isZero(traceId) {
+		return "", false
+	}
+
+	return traceId, true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
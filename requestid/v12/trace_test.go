@@ -0,0 +1,55 @@
+package requestid
+
+import "testing"
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		value   string
+		traceId string
+		ok      bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{" 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00 ", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", "", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", "", false},
+		{"0-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+		{"garbage", "", false},
+		{"", "", false},
+	}
+
+	for _, c := range cases {
+		traceId, ok := parseTraceparent(c.value)
+		if traceId != c.traceId || ok != c.ok {
+			t.Errorf("parseTraceparent(%q) = %q, %v; expected %q, %v", c.value, traceId, ok, c.traceId, c.ok)
+		}
+	}
+}
+
+func TestParseUberTraceId(t *testing.T) {
+	cases := []struct {
+		value   string
+		traceId string
+		ok      bool
+	}{
+		{"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"a3ce929d0e0e4736:00f067aa0ba902b7:0:1", "a3ce929d0e0e4736", true},
+		{"A3CE929D0E0E4736:00f067aa0ba902b7:0:1", "a3ce929d0e0e4736", true},
+		{"a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A1", "a3ce929d0e0e4736", true},
+		{"0:00f067aa0ba902b7:0:1", "", false},
+		{"a3ce929d0e0e4736:00f067aa0ba902b7:0", "", false},
+		{"4bf92f3577b34da6a3ce929d0e0e47360:00f067aa0ba902b7:0:1", "", false},
+		{"xyz:00f067aa0ba902b7:0:1", "", false},
+		{"", "", false},
+	}
+
+	for _, c := range cases {
+		traceId, ok := parseUberTraceId(c.value)
+		if traceId != c.traceId || ok != c.ok {
+			t.Errorf("parseUberTraceId(%q) = %q, %v; expected %q, %v", c.value, traceId, ok, c.traceId, c.ok)
+		}
+	}
+}